@@ -0,0 +1,118 @@
+package components
+
+import (
+	"fmt"
+	"slices"
+
+	"gloomberg/internal/alerts"
+	"gloomberg/internal/utils"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/table"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Overlay listing the session's alerts, alerts can be deleted from here.
+type AlertList struct {
+	Session *utils.Session
+	Width   int
+	Height  int
+
+	table table.Model
+}
+
+func (a *AlertList) renderRows() {
+	var rows []table.Row
+	for _, alert := range a.Session.State.Alerts {
+		lastFired := "never"
+		if !alert.LastFired.IsZero() {
+			lastFired = alert.LastFired.Local().Format("01/02 03:04 PM")
+		}
+		rows = append(rows, table.Row{alert.String(), lastFired, alert.ID})
+	}
+	a.table.SetRows(rows)
+}
+
+func (a *AlertList) Init() tea.Cmd {
+	accentColor := a.Session.Config.String("theme.accentColor")
+	a.table = table.New(
+		table.WithFocused(true),
+		table.WithColumns([]table.Column{
+			{Title: "Alert", Width: int(float64(a.Width) * .65)},
+			{Title: "Last fired", Width: int(float64(a.Width)*.35) - 4},
+			{Title: "id", Width: 0},
+		}),
+		table.WithHeight(a.Height),
+	)
+	a.table.SetStyles(table.Styles{
+		Header: a.Session.Renderer.NewStyle().
+			Align(lipgloss.Center).
+			Bold(true).
+			Foreground(lipgloss.Color("#FFFFFF")),
+		Cell:     a.Session.Renderer.NewStyle(),
+		Selected: a.Session.Renderer.NewStyle().Bold(true).Foreground(lipgloss.Color(accentColor)),
+	})
+	a.renderRows()
+	return nil
+}
+
+func (a *AlertList) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		a.Width = msg.Width / 2
+		a.Height = int(float64(msg.Height) * .8)
+		a.table.SetHeight(a.Height)
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "esc":
+			return a, func() tea.Msg { return utils.ModalCloseMsg(true) }
+		case "x", "delete":
+			row := a.table.SelectedRow()
+			if row == nil {
+				return a, nil
+			}
+			id := row[2]
+			a.Session.State.Alerts = slices.DeleteFunc(a.Session.State.Alerts, func(alert alerts.Alert) bool {
+				return alert.ID == id
+			})
+			a.Session.SaveState()
+			a.renderRows()
+			return a, func() tea.Msg {
+				return utils.SendNotificationMsg{
+					Message:     fmt.Sprintf("Deleted alert %s", row[0]),
+					DisplayTime: 3000,
+				}
+			}
+		}
+	}
+
+	var cmd tea.Cmd
+	a.table, cmd = a.table.Update(msg)
+	return a, cmd
+}
+
+func (a *AlertList) View() string {
+	style := a.Session.Renderer.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		Width(a.Width)
+
+	if len(a.Session.State.Alerts) == 0 {
+		return style.Height(5).Align(lipgloss.Center, lipgloss.Center).
+			Render("No alerts yet, press A on a stock or commodity to create one")
+	}
+	return style.Render(a.table.View())
+}
+
+func (a *AlertList) GetKeys() []key.Binding {
+	return []key.Binding{
+		key.NewBinding(
+			key.WithKeys("esc"),
+			key.WithHelp("<esc>", "close"),
+		),
+		key.NewBinding(
+			key.WithKeys("x", "delete"),
+			key.WithHelp("x", "delete alert"),
+		),
+	}
+}
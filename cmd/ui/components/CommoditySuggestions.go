@@ -14,6 +14,7 @@ import (
 	"github.com/charmbracelet/bubbles/list"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/log"
 )
 
 type Suggestion struct {
@@ -37,7 +38,7 @@ func (e Suggestion) FilterValue() string {
 }
 
 // Return all stocks accessible by FMP.
-func GetStockSuggestions(symbol string) []Suggestion {
+func GetStockSuggestions(symbol string, logger *log.Logger) []Suggestion {
 	// NOTE: QueryEscape formats characters like spaces so the request doesn't break.
 	// Also manually defining exchanges because yahoo finance doesn't support currency exchange.
 	url := fmt.Sprintf("https://financialmodelingprep.com/api/v3/search?query=%s&exchange=PNK,NASDAQ,NYSE,BSE,XETRA,LSE,AMEX,HKSE,JPX,ASX,SHZ,NSE,EURONEXT,SHH,TSX&apikey=%s", url.QueryEscape(symbol), os.Getenv("FMP_KEY"))
@@ -46,26 +47,27 @@ func GetStockSuggestions(symbol string) []Suggestion {
 	resp, err := client.Get(url)
 
 	if err != nil {
-		utils.UserLog.Fatal("Fatal error ocurred while requesting listed stocks from GetStockSuggestions()", err)
+		logger.Fatal("Fatal error ocurred while requesting listed stocks from GetStockSuggestions()", err)
 	}
 	defer resp.Body.Close()
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		utils.UserLog.Fatal("Fatal error occurred while reading body in GetStockSuggestions()", err)
+		logger.Fatal("Fatal error occurred while reading body in GetStockSuggestions()", err)
 	}
 
 	var list []Suggestion
 	err = json.Unmarshal(body, &list)
 
 	if err != nil {
-		utils.UserLog.Fatalf("Fatal error occurred while Unmarshaling StockList in GetStockSuggestions() err: %s, JSON %b", err, body)
+		logger.Fatalf("Fatal error occurred while Unmarshaling StockList in GetStockSuggestions() err: %s, JSON %b", err, body)
 	}
 
 	return list
 }
 
 type CommoditySuggestions struct {
+	Session     *utils.Session
 	Symbols     []Suggestion
 	SearchQuery string
 	List        list.Model
@@ -79,7 +81,7 @@ type CommoditySuggestions struct {
 }
 
 func (s *CommoditySuggestions) Init() tea.Cmd {
-	s.Symbols = GetStockSuggestions(s.SearchQuery)
+	s.Symbols = GetStockSuggestions(s.SearchQuery, s.Session.Log)
 
 	// Convert the symbols list to a list of item interfaces (typejack)
 	items := make([]list.Item, len(s.Symbols))
@@ -112,7 +114,7 @@ func (s *CommoditySuggestions) Init() tea.Cmd {
 	}
 
 	// Change the styling of the currently selecte
-	accentColor := lipgloss.Color(utils.Koanf.String("theme.accentColor"))
+	accentColor := lipgloss.Color(s.Session.Config.String("theme.accentColor"))
 	delegate.Styles.SelectedTitle = delegate.Styles.SelectedTitle.Foreground(accentColor).BorderForeground(accentColor)
 	delegate.Styles.SelectedDesc = delegate.Styles.SelectedDesc.Foreground(accentColor).BorderForeground(accentColor)
 
@@ -135,7 +137,7 @@ func (s *CommoditySuggestions) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		case "esc":
 			// only close the overlay if the user isn't currently searching
 			if !s.List.SettingFilter() {
-				utils.UserLog.Debug("Closing CommoditySuggestions modal.")
+				s.Session.Log.Debug("Closing CommoditySuggestions modal.")
 				return s, func() tea.Msg { return utils.ModalCloseMsg(true) }
 			}
 		}
@@ -149,10 +151,10 @@ func (s *CommoditySuggestions) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 }
 
 func (s *CommoditySuggestions) View() string {
-	titleStyle := utils.Renderer.NewStyle().Bold(true).Foreground(lipgloss.Color(utils.Koanf.String("theme.accentColor")))
-	listStyle := utils.Renderer.NewStyle().Border(lipgloss.RoundedBorder()).Width(s.Width).Height(s.Height)
+	titleStyle := s.Session.Renderer.NewStyle().Bold(true).Foreground(lipgloss.Color(s.Session.Config.String("theme.accentColor")))
+	listStyle := s.Session.Renderer.NewStyle().Border(lipgloss.RoundedBorder()).Width(s.Width).Height(s.Height)
 	s.List.Styles.Title = titleStyle
-	s.List.Styles.ActivePaginationDot = utils.Renderer.NewStyle().Foreground(lipgloss.Color(utils.Koanf.String("theme.accentColor")))
+	s.List.Styles.ActivePaginationDot = s.Session.Renderer.NewStyle().Foreground(lipgloss.Color(s.Session.Config.String("theme.accentColor")))
 	return listStyle.Render(s.List.View())
 }
 
@@ -0,0 +1,140 @@
+package components
+
+import (
+	"fmt"
+	"time"
+
+	"gloomberg/internal/hub"
+	"gloomberg/internal/utils"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/table"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Overlay listing every news source and how its last fetches went.
+type SourceList struct {
+	Session *utils.Session
+	Width   int
+	Height  int
+
+	table   table.Model
+	sources []hub.SourceHealth
+}
+
+// Format how long ago t was, e.g. "3m ago".
+func ago(t time.Time) string {
+	if t.IsZero() {
+		return "never"
+	}
+	d := time.Since(t)
+	switch {
+	case d < time.Minute:
+		return fmt.Sprintf("%ds ago", int(d.Seconds()))
+	case d < time.Hour:
+		return fmt.Sprintf("%dm ago", int(d.Minutes()))
+	case d < 24*time.Hour:
+		return fmt.Sprintf("%dh ago", int(d.Hours()))
+	}
+	return t.Local().Format("01/02 03:04 PM")
+}
+
+func (s *SourceList) renderRows() {
+	var rows []table.Row
+	for _, source := range s.sources {
+		var status, latency string
+		switch {
+		case source.LastAttempt.IsZero():
+			status = "…"
+		case source.LastError != "":
+			status = "\033[38;5;196m✗" // red
+		default:
+			status = "\033[38;5;46m✓" // green
+		}
+		if !source.LastAttempt.IsZero() {
+			latency = source.Latency.Round(time.Millisecond).String()
+		}
+		rows = append(rows, table.Row{
+			status,
+			source.Name,
+			source.Category,
+			ago(source.LastSuccess),
+			fmt.Sprintf("%d", source.Items),
+			latency,
+			source.LastError,
+		})
+	}
+	s.table.SetRows(rows)
+}
+
+func (s *SourceList) Init() tea.Cmd {
+	accentColor := s.Session.Config.String("theme.accentColor")
+	s.table = table.New(
+		table.WithFocused(true),
+		table.WithColumns([]table.Column{
+			{Title: "", Width: 2},
+			{Title: "Source", Width: int(float64(s.Width) * .2)},
+			{Title: "Category", Width: int(float64(s.Width) * .1)},
+			{Title: "Last success", Width: int(float64(s.Width) * .14)},
+			{Title: "Items", Width: int(float64(s.Width) * .07)},
+			{Title: "Latency", Width: int(float64(s.Width) * .1)},
+			{Title: "Last error", Width: int(float64(s.Width)*.39) - 16},
+		}),
+		table.WithHeight(s.Height),
+	)
+	s.table.SetStyles(table.Styles{
+		Header: s.Session.Renderer.NewStyle().
+			Align(lipgloss.Center).
+			Bold(true).
+			Foreground(lipgloss.Color("#FFFFFF")),
+		Cell:     s.Session.Renderer.NewStyle(),
+		Selected: s.Session.Renderer.NewStyle().Bold(true).Foreground(lipgloss.Color(accentColor)),
+	})
+	s.sources = hub.Shared.SourceHealth()
+	s.renderRows()
+	return nil
+}
+
+func (s *SourceList) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		s.Width = int(float64(msg.Width) * .8)
+		s.Height = int(float64(msg.Height) * .8)
+		s.table.SetHeight(s.Height)
+	case hub.SourceHealthMsg:
+		s.sources = msg
+		s.renderRows()
+		return s, nil
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "esc":
+			return s, func() tea.Msg { return utils.ModalCloseMsg(true) }
+		}
+	}
+
+	var cmd tea.Cmd
+	s.table, cmd = s.table.Update(msg)
+	return s, cmd
+}
+
+func (s *SourceList) View() string {
+	style := s.Session.Renderer.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		Width(s.Width)
+
+	if len(s.sources) == 0 {
+		return style.Height(5).Align(lipgloss.Center, lipgloss.Center).
+			Render("No news sources, add some to news.sources in the config")
+	}
+	return style.Render(s.table.View())
+}
+
+func (s *SourceList) GetKeys() []key.Binding {
+	return []key.Binding{
+		key.NewBinding(
+			key.WithKeys("esc"),
+			key.WithHelp("<esc>", "close"),
+		),
+	}
+}
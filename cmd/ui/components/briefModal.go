@@ -0,0 +1,275 @@
+package components
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"gloomberg/internal/scraping"
+	"gloomberg/internal/utils"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/glamour"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Asks the dashboard to open the article with the given ID.
+type OpenArticleMsg string
+
+// Sent once the brief is written.
+type briefMsg struct {
+	brief scraping.Brief
+	err   error
+}
+
+// Pop-up with a digest of the headlines, the watchlist and commodities.
+type BriefModal struct {
+	Session *utils.Session
+	// what the brief is written from
+	Input scraping.BriefInput
+	// every article in the news table by ID, the brief's sources are looked up here
+	Articles map[string]scraping.NewsArticle
+	W        int
+	H        int
+
+	vp     viewport.Model
+	styler *glamour.TermRenderer
+
+	// whether the brief is being written
+	loading bool
+	// why the brief couldn't be written
+	err   error
+	brief scraping.Brief
+	// IDs of the articles the brief cites, in the order they're numbered
+	sources []string
+	// cancel function for the brief being written
+	cancel context.CancelFunc
+}
+
+func (b *BriefModal) Init() tea.Cmd {
+	b.vp = viewport.New(b.W, b.H)
+	b.vp.Style = b.Session.Renderer.NewStyle().
+		Border(lipgloss.NormalBorder()).
+		Width(b.W)
+
+	var err error
+	b.styler, err = glamour.NewTermRenderer(
+		glamour.WithStyles(utils.CreateMarkdownUserConfig(b.Session.Config.String("theme.accentColor"))),
+		glamour.WithWordWrap(b.W-5),
+	)
+	if err != nil {
+		b.Session.Log.Errorf("Cannot create glamour renderer %s", err)
+	}
+	return b.generate(false)
+}
+
+// Write the brief, or use this hour's one unless regenerate is set.
+func (b *BriefModal) generate(regenerate bool) tea.Cmd {
+	b.loading = true
+	b.err = nil
+	var ctx context.Context
+	ctx, b.cancel = context.WithCancel(context.Background())
+
+	config, input := b.Session.Config, b.Input
+	return func() tea.Msg {
+		brief, err := scraping.GenerateBrief(ctx, config, input, regenerate)
+		// closed while it was being written
+		if ctx.Err() != nil {
+			return nil
+		}
+		return briefMsg{brief: brief, err: err}
+	}
+}
+
+// Number the articles the brief cites and render it into the viewport.
+func (b *BriefModal) render() {
+	b.sources = nil
+	// the numbers of a section's articles, e.g. " [1] [4]"
+	cite := func(section scraping.BriefSection) string {
+		var refs strings.Builder
+		for _, id := range section.Articles {
+			if _, ok := b.Articles[id]; !ok {
+				// dropped from the news table since the brief was written
+				continue
+			}
+			n := slices.Index(b.sources, id)
+			if n < 0 {
+				b.sources = append(b.sources, id)
+				n = len(b.sources) - 1
+			}
+			fmt.Fprintf(&refs, " [%d]", n+1)
+		}
+		return refs.String()
+	}
+
+	quotes := make(map[string]scraping.BriefQuote)
+	for _, quote := range b.Input.Quotes {
+		quotes[quote.Symbol] = quote
+	}
+
+	headlines := fmt.Sprintf("%d headlines", b.brief.Headlines)
+	if b.brief.Headlines == 1 {
+		headlines = "1 headline"
+	}
+	var md strings.Builder
+	fmt.Fprintf(&md, "# Market brief\n*%s · written by %s from %s*\n\n",
+		b.brief.GeneratedAt.Format("01/02 03:04 PM"), b.brief.GeneratedBy, headlines)
+	fmt.Fprintf(&md, "## Macro\n%s%s\n\n", b.brief.Macro.Summary, cite(b.brief.Macro))
+	if len(b.brief.Sectors) > 0 {
+		md.WriteString("## Sectors\n")
+		for _, sector := range b.brief.Sectors {
+			fmt.Fprintf(&md, "### %s\n%s%s\n\n", sector.Title, sector.Summary, cite(sector))
+		}
+	}
+	if len(b.brief.Watchlist) > 0 {
+		md.WriteString("## Watchlist\n")
+		for _, symbol := range b.brief.Watchlist {
+			heading := symbol.Title
+			if quote, ok := quotes[symbol.Title]; ok {
+				heading = fmt.Sprintf("%s (%+.2f%%)", symbol.Title, quote.PercentChange)
+			}
+			fmt.Fprintf(&md, "### %s\n%s%s\n\n", heading, symbol.Summary, cite(symbol))
+		}
+	}
+	if len(b.sources) > 0 {
+		md.WriteString("## Sources\n")
+		for i, id := range b.sources {
+			article := b.Articles[id]
+			fmt.Fprintf(&md, "%d. **%s** · %s, %s", i+1, article.Title, article.Source, article.PublicationDate.Format("01/02"))
+			if article.URL != "" {
+				fmt.Fprintf(&md, " · %s", article.URL)
+			}
+			md.WriteString("\n")
+		}
+	}
+
+	content, err := b.styler.Render(md.String())
+	if err != nil {
+		b.Session.Log.Errorf("Cannot render brief %s", err)
+		content = md.String()
+	}
+	b.vp.SetContent(content)
+	b.vp.GotoTop()
+}
+
+// Open the prompt for the number of a source to read.
+func (b *BriefModal) promptSource() tea.Cmd {
+	if len(b.sources) == 0 {
+		return nil
+	}
+	sources := b.sources
+	return func() tea.Msg {
+		return utils.PromptOpenMsg{
+			Prompt: fmt.Sprintf("Read source (1-%d): ", len(sources)),
+			CallbackFunc: func(s string) tea.Msg {
+				n, err := strconv.Atoi(strings.Trim(strings.TrimSpace(s), "[]"))
+				if err != nil || n < 1 || n > len(sources) {
+					return utils.SendNotificationMsg{Message: fmt.Sprintf("There is no source %q", s), DisplayTime: 3000}
+				}
+				return OpenArticleMsg(sources[n-1])
+			},
+		}
+	}
+}
+
+func (b *BriefModal) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		b.W = msg.Width / 2
+		b.H = int(float64(msg.Height) * .8)
+		b.vp.Width = b.W
+		b.vp.Height = b.H
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "esc":
+			return b, func() tea.Msg { return utils.ModalCloseMsg(true) }
+		case "r":
+			if !b.loading {
+				b.Session.Log.Info("Regenerating the brief")
+				return b, b.generate(true)
+			}
+		case "o":
+			if !b.loading {
+				return b, b.promptSource()
+			}
+		}
+	case utils.ModalCloseMsg:
+		if b.loading {
+			b.Session.Log.Info("Closing the brief and cancelling it")
+			b.cancel()
+		}
+	case briefMsg:
+		b.loading = false
+		if msg.err != nil {
+			b.Session.Log.Errorf("Cannot write the brief: %v", msg.err)
+			b.err = msg.err
+			return b, nil
+		}
+		b.brief = msg.brief
+		b.render()
+		return b, nil
+	}
+
+	var cmd tea.Cmd
+	b.vp, cmd = b.vp.Update(msg)
+	return b, cmd
+}
+
+func (b *BriefModal) View() string {
+	if b.loading || b.err != nil {
+		statusStyle := b.Session.Renderer.NewStyle().
+			Width(b.W).
+			Height(10).
+			Align(lipgloss.Center, lipgloss.Center).
+			Border(lipgloss.NormalBorder())
+		if b.err != nil {
+			return statusStyle.Render(fmt.Sprintf(" Couldn't write the brief\n%s\n\nPress r to try again", b.err))
+		}
+		return statusStyle.Render(fmt.Sprintf("󰎕 Writing the brief from %d headlines\n\nPress esc to cancel", min(len(b.Input.News), b.maxHeadlines())))
+	}
+	return b.vp.View()
+}
+
+// How many headlines the brief is written from at most.
+func (b *BriefModal) maxHeadlines() int {
+	if limit := b.Session.Config.Int("news.brief.max_headlines"); limit > 0 {
+		return limit
+	}
+	return len(b.Input.News)
+}
+
+func (b *BriefModal) GetKeys() []key.Binding {
+	keys := []key.Binding{
+		key.NewBinding(
+			key.WithKeys("esc"),
+			key.WithHelp("<esc>", "close brief"),
+		),
+		key.NewBinding(
+			key.WithKeys("j"),
+			key.WithHelp("<j>", "scroll down"),
+		),
+		key.NewBinding(
+			key.WithKeys("k"),
+			key.WithHelp("<k>", "scroll up"),
+		),
+	}
+	if !b.loading {
+		keys = append(keys,
+			key.NewBinding(
+				key.WithKeys("r"),
+				key.WithHelp("<r>", "regenerate"),
+			),
+		)
+	}
+	if len(b.sources) > 0 {
+		keys = append(keys, key.NewBinding(
+			key.WithKeys("o"),
+			key.WithHelp("<o>", "read source"),
+		))
+	}
+	return keys
+}
@@ -0,0 +1,232 @@
+package components
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"gloomberg/internal/llm"
+	"gloomberg/internal/utils"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Sent by the prompt with a question about the article with the given ID.
+type askArticleMsg struct {
+	id       string
+	question string
+}
+
+// The answer so far, sent while the model is writing it.
+type chatChunkMsg struct {
+	id     string
+	answer string
+}
+
+// Sent once the model is done answering.
+type chatDoneMsg struct {
+	id     string
+	answer string
+	err    error
+}
+
+// How often the answer being written is rendered again.
+const chatChunkInterval = 100 * time.Millisecond
+
+const chatPrompt = `You answer questions about a news article for a reader in a terminal.
+Base your answers on the article below. If it doesn't answer the question, say so,
+and say when you're using knowledge from outside the article.
+Keep answers short and use markdown.
+
+Title: %s
+Source: %s
+Published: %s
+
+%s`
+
+// Whether the chat pane is showing.
+func (n *NewsModal) chatOpen() bool {
+	return n.asking || len(n.Chats[n.Article.ID()]) > 0 || n.chatErr != ""
+}
+
+// Open the prompt for a question about the article.
+func (n *NewsModal) promptQuestion() tea.Cmd {
+	var problem string
+	switch {
+	case n.loading:
+		problem = "Wait for the article to load before asking about it"
+	case n.asking:
+		problem = "Still answering the last question"
+	case strings.TrimSpace(n.Article.Content) == "":
+		problem = "This article has no text to ask about"
+	}
+	if problem != "" {
+		return func() tea.Msg { return utils.SendNotificationMsg{Message: problem, DisplayTime: 3000} }
+	}
+
+	id := n.Article.ID()
+	return func() tea.Msg {
+		return utils.PromptOpenMsg{
+			Prompt: "Ask about this article: ",
+			CallbackFunc: func(s string) tea.Msg {
+				return askArticleMsg{id: id, question: strings.TrimSpace(s)}
+			},
+		}
+	}
+}
+
+// Send the question, with the article and the conversation so far, to the model.
+func (n *NewsModal) ask(question string) tea.Cmd {
+	id := n.Article.ID()
+	n.Chats[id] = append(n.Chats[id], llm.Message{Role: llm.User, Content: question})
+	n.asking = true
+	n.answer = ""
+	n.chatErr = ""
+	n.chatFocused = true
+	n.layout()
+	n.renderChat()
+
+	article := *n.Article
+	history := slices.Clone(n.Chats[id])
+	session := n.Session
+	var ctx context.Context
+	ctx, n.chatCancel = context.WithCancel(context.Background())
+
+	n.Session.Log.Infof("Asking about %s: %s", article.URL, question)
+	return func() tea.Msg {
+		provider, err := llm.FromConfig(session.Config)
+		if err != nil {
+			return chatDoneMsg{id: id, err: err}
+		}
+
+		system := fmt.Sprintf(chatPrompt, article.Title, article.Source, article.PublicationDate.Format("01/02/2006"), article.Content)
+		req := llm.Request{Messages: append([]llm.Message{{Role: llm.System, Content: system}}, history...)}
+
+		var answer strings.Builder
+		var last time.Time
+		text, err := provider.Stream(ctx, req, func(chunk string) {
+			answer.WriteString(chunk)
+			if ctx.Err() == nil && time.Since(last) >= chatChunkInterval {
+				last = time.Now()
+				session.Send(chatChunkMsg{id: id, answer: answer.String()})
+			}
+		})
+		// the question was cancelled, the modal has moved on
+		if ctx.Err() != nil {
+			return nil
+		}
+		return chatDoneMsg{id: id, answer: text, err: err}
+	}
+}
+
+// Stop answering and forget the unanswered question.
+func (n *NewsModal) cancelQuestion() {
+	if !n.asking {
+		return
+	}
+	n.Session.Log.Info("Cancelling question about the article")
+	n.chatCancel()
+	n.asking = false
+	id := n.Article.ID()
+	if history := n.Chats[id]; len(history) > 0 {
+		n.Chats[id] = history[:len(history)-1]
+	}
+}
+
+func (n *NewsModal) finishAnswer(msg chatDoneMsg) {
+	n.asking = false
+	n.answer = ""
+	history := n.Chats[msg.id]
+	if msg.err != nil {
+		n.Session.Log.Errorf("Cannot answer question about the article: %v", msg.err)
+		// the question is dropped so the conversation sent next time only has answered questions
+		if len(history) > 0 {
+			n.chatErr = fmt.Sprintf("Couldn't answer %q: %s", history[len(history)-1].Content, msg.err)
+			n.Chats[msg.id] = history[:len(history)-1]
+		}
+	} else {
+		n.Chats[msg.id] = append(history, llm.Message{Role: llm.Assistant, Content: strings.TrimSpace(msg.answer)})
+	}
+	n.layout()
+	n.renderChat()
+}
+
+// Render the conversation about the current article into the chat pane.
+func (n *NewsModal) renderChat() {
+	if !n.chatOpen() {
+		return
+	}
+	var b strings.Builder
+	for i, msg := range n.Chats[n.Article.ID()] {
+		if msg.Role != llm.User {
+			fmt.Fprintf(&b, "%s\n\n", msg.Content)
+			continue
+		}
+		if i > 0 {
+			b.WriteString("---\n\n")
+		}
+		fmt.Fprintf(&b, "**You:** %s\n\n", msg.Content)
+	}
+	if n.asking {
+		if n.answer == "" {
+			b.WriteString("*Thinking…*")
+		} else {
+			b.WriteString(n.answer)
+		}
+	}
+	if n.chatErr != "" {
+		fmt.Fprintf(&b, "*%s*", n.chatErr)
+	}
+
+	chat, err := n.styler.Render(b.String())
+	if err != nil {
+		n.Session.Log.Errorf("Cannot render chat %s", err)
+		chat = b.String()
+	}
+	n.chatVP.SetContent(chat)
+	n.chatVP.GotoBottom()
+}
+
+// Size the article and the chat pane under it, which takes the bottom of the modal when open.
+func (n *NewsModal) layout() {
+	height := n.H
+	if n.chatOpen() {
+		chatHeight := n.H * 2 / 5
+		n.chatVP.Height = chatHeight
+		height -= chatHeight
+	} else {
+		n.chatFocused = false
+	}
+	// room for the status line under the article
+	if n.streaming {
+		height--
+	}
+	n.vp.Height = height
+	n.vp.Width = n.W
+	n.chatVP.Width = n.W
+
+	border := n.Session.Renderer.NewStyle().Border(lipgloss.NormalBorder()).Width(n.W)
+	if n.chatFocused {
+		n.chatVP.Style = border.BorderForeground(lipgloss.Color(n.Session.Config.String("theme.accentColor")))
+	} else {
+		n.chatVP.Style = border
+	}
+}
+
+// Keep the chat pane up to date as the model answers.
+func (n *NewsModal) updateChat(msg tea.Msg) {
+	switch msg := msg.(type) {
+	case chatChunkMsg:
+		if n.asking && msg.id == n.Article.ID() {
+			n.answer = msg.answer
+			n.renderChat()
+		}
+	case chatDoneMsg:
+		if n.asking && msg.id == n.Article.ID() {
+			n.finishAnswer(msg)
+		}
+	}
+}
@@ -3,10 +3,10 @@ package components
 import (
 	"context"
 	"fmt"
+	"gloomberg/internal/llm"
 	"gloomberg/internal/scraping"
 	"gloomberg/internal/utils"
 	"strings"
-	"time"
 
 	"github.com/charmbracelet/bubbles/key"
 	"github.com/charmbracelet/bubbles/viewport"
@@ -24,6 +24,7 @@ type UpdateStatusMsg scraping.StatusUpdate
 
 // Pop-up model displaying news
 type NewsModal struct {
+	Session *utils.Session
 	Article *scraping.NewsArticle
 	// width
 	W int
@@ -31,7 +32,7 @@ type NewsModal struct {
 	// viewport model
 	vp viewport.Model
 
-	// glamour utils.Renderer
+	// glamour renderer
 	styler *glamour.TermRenderer
 
 	// context for prompt function.
@@ -40,45 +41,76 @@ type NewsModal struct {
 	newsCtxCancel context.CancelFunc
 	// whether or not the article has loaded
 	loading bool
+	// whether the article is being streamed into the viewport while it loads
+	streaming bool
+	// which extractor is streaming the article
+	streamingFrom string
 	// status message
 	statusMessage string
 
-	// channel for progress updates for newsscraping
-	progressChan chan scraping.StatusUpdate
+	// the story as told by each source that carries it, Article points into this
+	versions []scraping.NewsArticle
+	// index of the version being read
+	version int
+
+	// questions asked about each article and their answers by article ID, kept by
+	// whoever opens the modal so they last the session
+	Chats map[string][]llm.Message
+	// chat pane under the article
+	chatVP viewport.Model
+	// whether j/k scroll the chat pane instead of the article
+	chatFocused bool
+	// whether the model is answering a question
+	asking bool
+	// the answer so far
+	answer string
+	// why the last question couldn't be answered
+	chatErr string
+	// cancel function for the question being answered
+	chatCancel context.CancelFunc
 }
 
 // begin newsscraping
-func scrapeNews(article *scraping.NewsArticle, status *chan scraping.StatusUpdate, ctx context.Context) tea.Cmd {
+func scrapeNews(session *utils.Session, article *scraping.NewsArticle, status chan scraping.StatusUpdate, ctx context.Context) tea.Cmd {
 	log.Info("scrapeNews CMD")
 	return func() tea.Msg {
-		utils.UserLog.Info("scrapeNews Cmd run")
+		session.Log.Info("scrapeNews Cmd run")
 		go func() {
-			for progress := range *status {
-				utils.Program.Send(UpdateStatusMsg(progress))
+			for progress := range status {
+				// a cancelled scrape (e.g. after switching sources) shouldn't update the modal
+				if ctx.Err() != nil {
+					continue
+				}
+				session.Send(UpdateStatusMsg(progress))
 			}
 		}()
-		go scraping.PromptNewsURL(article, status, ctx) // needs to run in it's own routine for listen to workk
+		go scraping.PromptNewsURL(session.Config, article, status, ctx) // needs to run in it's own routine for listen to workk
 		return nil
 	}
 }
 
 func (n *NewsModal) styleArticle() (string, error) {
-	utils.UserLog.Info("Styling markdown")
-	md, err := n.styler.Render(n.Article.Content)
+	n.Session.Log.Info("Styling markdown")
+	return n.renderArticle(n.Article.Content, n.Article.Bullets)
+}
+
+// Render the article with the given content and bullets, which may still be streaming in.
+func (n *NewsModal) renderArticle(content string, bullets []string) (string, error) {
+	md, err := n.styler.Render(content)
 	if err != nil {
-		utils.UserLog.Errorf("Cannot render markdown content %s", err)
+		n.Session.Log.Errorf("Cannot render markdown content %s", err)
 	}
 
 	var header string
 
-	if len(n.Article.Bullets) > 0 {
+	if len(bullets) > 0 {
 		// TODO: build a list of bullets and render them in markdown
 		var builder strings.Builder
 
 		// building bullets as a (unrendered) list
-		for i, bullet := range n.Article.Bullets {
+		for i, bullet := range bullets {
 			// don't put a newline if we are at the last bullet point
-			if i < len(n.Article.Bullets)-1 {
+			if i < len(bullets)-1 {
 				builder.WriteString(fmt.Sprintf("- %s\n", bullet))
 			} else {
 				builder.WriteString(fmt.Sprintf("- %s", bullet))
@@ -88,21 +120,23 @@ func (n *NewsModal) styleArticle() (string, error) {
 		// NOTE: For some reason there needs to be two newlines for summary to render on a different line
 		// than published. Don't know why but if it works it works
 
-		header, err = n.styler.Render(fmt.Sprintf("# %s\n## %s\n*Published: %s* \n\n  Summary \n %s \n ---",
+		header, err = n.styler.Render(fmt.Sprintf("# %s\n## %s\n*Published: %s*%s \n\n  Summary \n %s \n ---",
 			n.Article.Title,
-			n.Article.Source,
+			n.sourceHeading(),
 			n.Article.PublicationDate.Format("01/02/2006"),
+			n.byline(),
 			builder.String()))
 
 	} else {
-		header, err = n.styler.Render(fmt.Sprintf("# %s\n## %s\n*Published: %s*",
+		header, err = n.styler.Render(fmt.Sprintf("# %s\n## %s\n*Published: %s*%s",
 			n.Article.Title,
-			n.Article.Source,
-			n.Article.PublicationDate.Format("01/02/2006")))
+			n.sourceHeading(),
+			n.Article.PublicationDate.Format("01/02/2006"),
+			n.byline()))
 
 	}
 	if err != nil {
-		utils.UserLog.Errorf("Cannot render markdown content %s", err)
+		n.Session.Log.Errorf("Cannot render markdown content %s", err)
 	}
 	return fmt.Sprintf("%s\n%s", header, md), nil
 }
@@ -112,49 +146,105 @@ func (n *NewsModal) Init() tea.Cmd {
 
 	// initialize viewport with full width but minimal height
 	n.vp = viewport.New(n.W, 1)
-	n.vp.Style = utils.Renderer.NewStyle().
+	n.vp.Style = n.Session.Renderer.NewStyle().
 		Border(lipgloss.NormalBorder()).
 		Padding(0, 0).
 		Width(n.W)
+	n.chatVP = viewport.New(n.W, 1)
+	if n.Chats == nil {
+		n.Chats = make(map[string][]llm.Message)
+	}
 
-	// initialize glamour utils.Renderer
+	// initialize glamour renderer
 	var err error
 	n.styler, err = glamour.NewTermRenderer(
-		glamour.WithStyles(utils.CreateMarkdownUserConfig()),
+		glamour.WithStyles(utils.CreateMarkdownUserConfig(n.Session.Config.String("theme.accentColor"))),
 		glamour.WithWordWrap(n.W-5),
 	)
 	if err != nil {
-		utils.UserLog.Errorf("Cannot create glamour utils.Renderer %s", err)
+		n.Session.Log.Errorf("Cannot create glamour renderer %s", err)
 	}
 
+	// the first version is the article itself, without its duplicates
+	first := *n.Article
+	first.Duplicates = nil
+	n.versions = append([]scraping.NewsArticle{first}, n.Article.Duplicates...)
+	n.version = 0
+	n.Article = &n.versions[0]
+
+	return n.load()
+}
+
+// Show the current version of the article, scraping it first if needed.
+func (n *NewsModal) load() tea.Cmd {
 	// if article is not readable, scrape it
 	if !n.Article.Readable {
-		utils.UserLog.Info("Article not readable, loading content")
+		n.Session.Log.Info("Article not readable, loading content")
 		n.loading = true
-		n.progressChan = make(chan scraping.StatusUpdate)
+		n.streaming = false
+		n.statusMessage = ""
 
-		var ctx context.Context
-		ctx, n.newsCtxCancel = context.WithCancel(context.Background())
-		n.newsCtx, n.newsCtxCancel = context.WithTimeout(ctx, 30*time.Second)
+		// the download and the model have their own timeouts, see llm.timeout
+		n.newsCtx, n.newsCtxCancel = context.WithCancel(context.Background())
 
+		// every scrape gets its own channel, a cancelled one closes only its own
+		progress := make(chan scraping.StatusUpdate)
 		return tea.Batch(
-			scrapeNews(n.Article, &n.progressChan, n.newsCtx),
+			scrapeNews(n.Session, n.Article, progress, n.newsCtx),
 		)
 
 	} else {
 		// if you don't need to scrape
 		content, err := n.styleArticle()
 		n.vp.SetContent(content)
+		n.vp.GotoTop()
 		if err != nil {
-			utils.UserLog.Errorf("Cannot render markdown content %s", err)
+			n.Session.Log.Errorf("Cannot render markdown content %s", err)
 		}
 		n.loading = false
-		n.vp.Height = n.H
+		n.layout()
+		n.renderChat()
 		return nil
 	}
 
 }
 
+// Who wrote the article and which extractor the text came from, shown after the publication date.
+func (n *NewsModal) byline() string {
+	var byline string
+	if n.Article.Author != "" {
+		byline = fmt.Sprintf(" · *By %s*", n.Article.Author)
+	}
+	if n.Article.ExtractedBy == "" {
+		return byline
+	}
+	return fmt.Sprintf("%s · *Extracted by %s*", byline, n.Article.ExtractedBy)
+}
+
+// The source shown under the headline, with which version this is when several sources carry it.
+func (n *NewsModal) sourceHeading() string {
+	if len(n.versions) < 2 {
+		return n.Article.Source
+	}
+	return fmt.Sprintf("%s (%d/%d sources)", n.Article.Source, n.version+1, len(n.versions))
+}
+
+// Switch to the next (delta 1) or previous (delta -1) source's version of the story.
+func (n *NewsModal) switchVersion(delta int) tea.Cmd {
+	if len(n.versions) < 2 {
+		return nil
+	}
+	if n.loading {
+		n.newsCtxCancel()
+	}
+	n.cancelQuestion()
+	n.chatErr = ""
+	n.version = (n.version + delta + len(n.versions)) % len(n.versions)
+	n.Article = &n.versions[n.version]
+	n.Session.Log.Infof("Switching to %s's version of the article", n.Article.Source)
+	return n.load()
+}
+
 func (n *NewsModal) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	var cmd tea.Cmd
 	switch msg := msg.(type) {
@@ -162,39 +252,72 @@ func (n *NewsModal) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		// update viewport size
 		n.W = msg.Width / 2
 		n.H = int(float64(msg.Height) * .8)
-		n.vp.Width = n.W
-		n.vp.Height = n.H
+		n.layout()
+		n.renderChat()
 
 	case tea.KeyMsg:
 		switch key := msg.String(); key {
 		case "esc":
 			return n, func() tea.Msg { return utils.ModalCloseMsg(true) }
+		case "s":
+			return n, n.switchVersion(1)
+		case "S":
+			return n, n.switchVersion(-1)
+		case "a":
+			return n, n.promptQuestion()
+		case "tab":
+			if n.chatOpen() {
+				n.chatFocused = !n.chatFocused
+				n.layout()
+			}
+			return n, nil
+		}
+		if n.chatFocused {
+			n.chatVP, cmd = n.chatVP.Update(msg)
+			return n, cmd
 		}
 
+	case askArticleMsg:
+		if msg.question == "" || msg.id != n.Article.ID() {
+			return n, nil
+		}
+		return n, n.ask(msg.question)
+	case chatChunkMsg, chatDoneMsg:
+		n.updateChat(msg)
+		return n, nil
+
 	case utils.ModalCloseMsg:
 		// this basically checks if we've scraped the news using ai
 		if n.loading {
-			utils.UserLog.Info("Closing news modal and cancelling network request")
+			n.Session.Log.Info("Closing news modal and cancelling network request")
 			n.newsCtxCancel()
 		}
+		n.cancelQuestion()
 	case UpdateContentMsg:
-		utils.UserLog.Info("Finished scraping article")
-		n.vp.Height = n.H
+		n.Session.Log.Info("Finished scraping article")
+		n.streaming = false
+		n.layout()
 		content, err := n.styleArticle()
 		if err != nil {
-			utils.UserLog.Errorf("Cannot render markdown content %s", err)
+			n.Session.Log.Errorf("Cannot render markdown content %s", err)
 		}
 		n.loading = false
 		n.vp.SetContent(content)
+		n.renderChat()
 	case UpdateStatusMsg:
 		// TODO: Modify code to constantly call Update with an UpdateStatusMsg,
 		// do this until loading is finished, then call UpdateContentMsg
+		if msg.Partial != nil {
+			return n, n.showPartial(msg)
+		}
 		var statusMsg string
 		switch msg.StatusCode {
 		case -1: // error case
 			// NOTE: Add red bold formatting to error message
 			statusMsg = fmt.Sprintf(" An error occured\n%s", msg.StatusMessage)
 			n.newsCtxCancel()
+			// show the error instead of the half streamed article
+			n.streaming = false
 		case 0:
 			statusMsg = "󰖟 Initialized scraping protocol"
 		case 1:
@@ -202,28 +325,64 @@ func (n *NewsModal) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		case 2:
 			statusMsg = "󰇚 Downloading article"
 		case 3:
-			statusMsg = " Scraping text from article"
+			statusMsg = fmt.Sprintf(" Extracting text with %s", msg.StatusMessage)
+			// an extractor that failed part way through may have streamed some of the article
+			n.streaming = false
 		case 4:
 			statusMsg = " Done"
-			utils.UserLog.Debug(statusMsg)
+			n.Session.Log.Debug(statusMsg)
 			return n, func() tea.Msg { return UpdateContentMsg(*n.Article) }
 		}
 
 		n.statusMessage = statusMsg
-		utils.UserLog.Info(statusMsg)
+		n.Session.Log.Info(statusMsg)
 	}
 	n.vp, cmd = n.vp.Update(msg)
 	return n, cmd
 }
 
+// Show the article as far as it's been extracted, keeping the scroll position
+// so the top can be read while the rest streams in.
+func (n *NewsModal) showPartial(msg UpdateStatusMsg) tea.Cmd {
+	if !n.loading {
+		return nil
+	}
+	content, err := n.renderArticle(msg.Partial.Content, msg.Partial.Bullets)
+	if err != nil {
+		n.Session.Log.Errorf("Cannot render markdown content %s", err)
+	}
+	if !n.streaming {
+		n.Session.Log.Infof("Streaming article from %s", msg.StatusMessage)
+		n.streaming = true
+		n.streamingFrom = msg.StatusMessage
+		n.layout()
+		n.vp.SetContent(content)
+		n.vp.GotoTop()
+		return nil
+	}
+	n.vp.SetContent(content)
+	return nil
+}
+
 func (n *NewsModal) View() string {
+	if n.chatOpen() && !n.loading {
+		return lipgloss.JoinVertical(lipgloss.Left, n.vp.View(), n.chatVP.View())
+	}
+	if n.streaming {
+		status := n.Session.Renderer.NewStyle().Faint(true).Width(n.W).
+			Render(fmt.Sprintf(" Writing with %s, press esc to cancel", n.streamingFrom))
+		return lipgloss.JoinVertical(lipgloss.Left, n.vp.View(), status)
+	}
 	if n.loading {
-		statusStyle := utils.Renderer.NewStyle().
+		statusStyle := n.Session.Renderer.NewStyle().
 			Width(n.W).
 			Height(10).
 			Align(lipgloss.Center, lipgloss.Center).
 			Border(lipgloss.NormalBorder())
 		responseUI := fmt.Sprintf("%s\n\n%s", n.statusMessage, "Press esc to cancel")
+		if len(n.versions) > 1 {
+			responseUI += fmt.Sprintf(", s to read %s instead", n.versions[(n.version+1)%len(n.versions)].Source)
+		}
 		return statusStyle.Render(responseUI)
 
 	} else {
@@ -232,7 +391,7 @@ func (n *NewsModal) View() string {
 }
 
 func (n *NewsModal) GetKeys() []key.Binding {
-	return []key.Binding{
+	keys := []key.Binding{
 		key.NewBinding(
 			key.WithKeys("esc"),
 			key.WithHelp("<esc>", "close article"),
@@ -246,4 +405,21 @@ func (n *NewsModal) GetKeys() []key.Binding {
 			key.WithHelp("<k>", "scroll up"),
 		),
 	}
+	keys = append(keys, key.NewBinding(
+		key.WithKeys("a"),
+		key.WithHelp("<a>", "ask about article"),
+	))
+	if n.chatOpen() {
+		keys = append(keys, key.NewBinding(
+			key.WithKeys("tab"),
+			key.WithHelp("<tab>", "scroll article/chat"),
+		))
+	}
+	if len(n.versions) > 1 {
+		keys = append(keys, key.NewBinding(
+			key.WithKeys("s", "S"),
+			key.WithHelp("<s/S>", "next/previous source"),
+		))
+	}
+	return keys
 }
@@ -2,15 +2,19 @@ package main
 
 import (
 	"context"
+	"crypto/sha256"
 	"errors"
 	"fmt"
 	"gloomberg/cmd/ui/views"
+	"gloomberg/internal/hub"
+	"gloomberg/internal/markets"
+	"gloomberg/internal/scraping"
 	"gloomberg/internal/utils"
+	"gloomberg/internal/webhook"
 	"io"
 	"net"
 	"os"
 	"os/signal"
-	"path/filepath"
 	"strconv"
 	"strings"
 	"syscall"
@@ -31,6 +35,7 @@ import (
 	"github.com/charmbracelet/wish/logging"
 	"github.com/muesli/termenv"
 	overlay "github.com/rmhubbert/bubbletea-overlay"
+	gossh "golang.org/x/crypto/ssh"
 )
 
 type Tab struct {
@@ -56,10 +61,18 @@ type Prompt struct {
 	Model    textinput.Model
 	Prompt   string
 	Callback func(string) tea.Msg
+	// called as the text changes, see utils.PromptOpenMsg
+	OnChange func(string) tea.Msg
+	// the text the prompt was opened with
+	Initial string
 }
 
 // The "entry" model.
 type MainModel struct {
+	// the session this model belongs to
+	session *utils.Session
+	// the session's subscription to the market data hub, the tabs watch their symbols on it
+	feed *hub.Subscription
 	// pointers to all the tabs
 	tabs []*Tab
 	// index of active tab in the list
@@ -91,49 +104,42 @@ type MappedModel interface {
 }
 
 func (m MainModel) Init() tea.Cmd {
-	// SECTION: Setup Configuration
-	configHome, err := os.UserHomeDir()
-
-	if err != nil {
-		utils.UserLog.Fatal("Error ocurred while loading config file path: %v", err)
-	}
-
-	configFilePath := filepath.Join(configHome, ".config", "gloom", "config.json")
-	utils.UserLog.Infof("Checking for config file at path %s", configFilePath)
-
-	utils.LoadDefaultConfig()
-
-	// Load prompt model
-	m.input = Prompt{
-		Model: textinput.New(),
-	}
-
-	// Check if user config file exists
-	if _, err := os.Stat(configFilePath); err == nil {
-		utils.UserLog.Infof("Config file found at %s, loading...", configFilePath)
-		utils.LoadUserConfig(configFilePath)
+	cmds := []tea.Cmd{tea.ClearScreen, tea.SetWindowTitle("gloom")}
+	// the program is running by now, so the latest data can be sent
+	m.feed.SendLatest()
+	// every tab is started so it can receive data while in the background
+	for _, t := range m.tabs {
+		cmds = append(cmds, t.model.Init())
 	}
-	tab := m.tabs[m.activeTab].model
-	return tea.Batch(tea.ClearScreen, tea.SetWindowTitle("gloom"), tab.Init())
+	return tea.Batch(cmds...)
 }
 
 func (m MainModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	tab := m.tabs[m.activeTab].model
 	var cmd tea.Cmd
-	if !m.overlayOpen && !m.input.Model.Focused() {
-		// Only send keypresses to the current tab if we are not in a modal right now
-		_, cmd = tab.Update(msg)
-	} else if m.overlayOpen {
-		// Send updates to the foreground if it's open
-		_, cmd = m.overlayManager.Foreground.Update(msg)
-		if _, ok := msg.(tea.KeyMsg); !ok {
-			// If the message is not a KeyMsg, also send it to the background tab
-			_, _ = tab.Update(msg)
+	// commands returned by the tabs and the overlay
+	var modelCmds []tea.Cmd
+	if _, isKey := msg.(tea.KeyMsg); isKey {
+		if m.input.Model.Focused() {
+			// keypresses only go to the prompt while it's open
+		} else if m.overlayOpen {
+			// Send keypresses to the foreground if it's open
+			_, c := m.overlayManager.Foreground.Update(msg)
+			modelCmds = append(modelCmds, c)
+		} else {
+			// Only send keypresses to the current tab if we are not in a modal right now
+			_, c := tab.Update(msg)
+			modelCmds = append(modelCmds, c)
 		}
-	}
-	if m.input.Model.Focused() {
-		if _, ok := msg.(tea.KeyMsg); !ok {
-			_, cmd = tab.Update(msg)
+	} else {
+		if m.overlayOpen {
+			_, c := m.overlayManager.Foreground.Update(msg)
+			modelCmds = append(modelCmds, c)
+		}
+		// every tab gets other messages, so tabs in the background stay up to date
+		for _, t := range m.tabs {
+			_, c := t.model.Update(msg)
+			modelCmds = append(modelCmds, c)
 		}
 	}
 
@@ -145,6 +151,10 @@ func (m MainModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			// if the user presses escape break out of the prompt
 			if msg.String() == "esc" {
 				m.input.Model.Blur()
+				if m.input.OnChange != nil {
+					initial, onChange := m.input.Initial, m.input.OnChange
+					cmd = func() tea.Msg { return onChange(initial) }
+				}
 			} else if msg.String() == "enter" {
 				m.input.Model.Blur()
 				if m.input.Callback != nil {
@@ -156,7 +166,12 @@ func (m MainModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				}
 				break
 			} else {
+				previous := m.input.Model.Value()
 				m.input.Model, cmd = m.input.Model.Update(msg)
+				if value := m.input.Model.Value(); value != previous && m.input.OnChange != nil {
+					onChange := m.input.OnChange
+					cmd = tea.Batch(cmd, func() tea.Msg { return onChange(value) })
+				}
 				break
 			}
 
@@ -174,7 +189,7 @@ func (m MainModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			fallthrough
 		case "q":
 			if !m.input.Model.Focused() && !m.overlayOpen {
-				utils.UserLog.Info("Exiting on user request")
+				m.session.Log.Info("Exiting on user request")
 				return m, tea.Batch(tea.ClearScreen, tea.Quit)
 			}
 
@@ -185,7 +200,7 @@ func (m MainModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 
 	case utils.ModalCloseMsg:
-		utils.UserLog.Info("Exiting overlay")
+		m.session.Log.Info("Exiting overlay")
 		m.overlayOpen = false
 		m.overlayManager.Foreground.Update(msg)
 		m.overlayManager.Background = nil
@@ -196,7 +211,7 @@ func (m MainModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return m, tea.ClearScreen
 
 	case TabChangeMsg:
-		utils.UserLog.Infof("Switching to view tabs[%d]", int(msg))
+		m.session.Log.Infof("Switching to view tabs[%d]", int(msg))
 		m.activeTab = int(msg)
 
 	case views.DisplayOverlayMsg:
@@ -204,7 +219,7 @@ func (m MainModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		// NOTE: The code for pressing escape to exit the overlay
 		//  is in the keypress part of this switch statement
 		if !m.overlayOpen {
-			utils.UserLog.Info("displaying overlay")
+			m.session.Log.Info("displaying overlay")
 			// Create the overlay model
 			overlayModel := overlay.New(msg, m, overlay.Center, overlay.Center, 0, 0)
 
@@ -228,6 +243,10 @@ func (m MainModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.input.Model.Focus()
 		m.input.Prompt = msg.Prompt
 		m.input.Callback = msg.CallbackFunc
+		m.input.OnChange = msg.ChangeFunc
+		m.input.Initial = msg.Value
+		m.input.Model.SetValue(msg.Value)
+		m.input.Model.CursorEnd()
 
 	case utils.SendNotificationMsg:
 		m.NotificationText = msg.Message
@@ -241,27 +260,16 @@ func (m MainModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		log.Info("hiding notification")
 	}
 
-	updatedModel := MainModel{
-		tabs:                m.tabs,
-		activeTab:           m.activeTab,
-		overlayManager:      m.overlayManager,
-		overlayOpen:         m.overlayOpen,
-		Width:               m.Width,
-		input:               m.input,
-		NotificationText:    m.NotificationText,
-		ShowingNotification: m.ShowingNotification,
-	}
-
-	return updatedModel, cmd
+	return m, tea.Batch(append(modelCmds, cmd)...)
 
 }
 
-func RenderHelp(keys []key.Binding, width int) string {
+func RenderHelp(session *utils.Session, keys []key.Binding, width int) string {
 	var b strings.Builder
 
-	accentColor := utils.Koanf.String("theme.accentColor")
+	accentColor := session.Config.String("theme.accentColor")
 
-	boldStyle := utils.Renderer.NewStyle().
+	boldStyle := session.Renderer.NewStyle().
 		Bold(true).
 		Foreground(lipgloss.Color(accentColor))
 	for _, binds := range keys {
@@ -274,14 +282,14 @@ func RenderHelp(keys []key.Binding, width int) string {
 func (m MainModel) View() string {
 	tab := m.tabs[m.activeTab].model
 
-	accentColor := utils.Koanf.String("theme.accentColor")
+	accentColor := m.session.Config.String("theme.accentColor")
 
 	// build tabbar
 	var b strings.Builder
 	for i, t := range m.tabs {
 		var tabText string
 		if i == m.activeTab {
-			bg := utils.Renderer.NewStyle().Background(lipgloss.Color(accentColor))
+			bg := m.session.Renderer.NewStyle().Background(lipgloss.Color(accentColor))
 			tabText = bg.Render(fmt.Sprintf(" (%d) %s ", i+1, t.name))
 		} else {
 			tabText = fmt.Sprintf(" (%d) %s ", i+1, t.name)
@@ -289,34 +297,42 @@ func (m MainModel) View() string {
 		b.WriteString(tabText)
 	}
 
+	// market hours on the right of the tabbar
+	status := renderMarketStatus(m.session, time.Now())
+	if gap := m.Width - lipgloss.Width(b.String()) - lipgloss.Width(status); gap > 0 {
+		b.WriteString(strings.Repeat(" ", gap))
+		b.WriteString(status)
+	}
+
 	// What text to show on the bottom
 	var bottomText string
 	var screen string
 
+	// keys of whatever has focus, the overlay when one is open
+	var keyBinds []key.Binding
 	if !m.overlayOpen {
 		screen = tab.View()
-		// if the prompt is open show it
-		if m.input.Model.Focused() {
-			// prompt is bold and in accent color
-			styledPrompt := utils.Renderer.NewStyle().Foreground(lipgloss.Color(accentColor)).Bold(true).SetString(m.input.Prompt).Render()
-			// NOTE: [:2] removes the leading "> " from the styledPrompt
-			bottomText = fmt.Sprintf("%s%s", styledPrompt, m.input.Model.View()[2:])
-		} else {
-			// render help key when prompt is not opened
-			bottomText = RenderHelp(tab.GetKeys(), m.Width)
-		}
-
+		keyBinds = tab.GetKeys()
 	} else {
 		// FIXME: temporary solution for showing keybinds in news modal
 		// in the future, this should be refatored so seach model manages THEIR OWN
 		// overlay logic.
-		var keyBinds = m.overlayManager.foreground.GetKeys()
+		keyBinds = m.overlayManager.foreground.GetKeys()
 
 		lines := strings.Split(m.overlayManager.View(), "\n")
 
 		screen = strings.Join(lines[:len(lines)-1], "\n")
-		bottomText = RenderHelp(keyBinds, m.Width)
+	}
 
+	// if the prompt is open show it, overlays can open prompts too
+	if m.input.Model.Focused() {
+		// prompt is bold and in accent color
+		styledPrompt := m.session.Renderer.NewStyle().Foreground(lipgloss.Color(accentColor)).Bold(true).SetString(m.input.Prompt).Render()
+		// NOTE: [:2] removes the leading "> " from the styledPrompt
+		bottomText = fmt.Sprintf("%s%s", styledPrompt, m.input.Model.View()[2:])
+	} else {
+		// render help key when prompt is not opened
+		bottomText = RenderHelp(m.session, keyBinds, m.Width)
 	}
 	if m.ShowingNotification && !m.input.Model.Focused() {
 		log.Infof("Showing notification text: %s", m.NotificationText)
@@ -325,18 +341,48 @@ func (m MainModel) View() string {
 	return lipgloss.JoinVertical(0, b.String(), screen, bottomText)
 }
 
+// Show whether each region's exchange is open, e.g. "US ● Open  EU ● Closed".
+func renderMarketStatus(session *utils.Session, now time.Time) string {
+	var parts []string
+	for _, e := range markets.Exchanges {
+		var color string
+		status := e.StatusAt(now)
+		switch status {
+		case markets.Open:
+			color = "#00FF00"
+		case markets.PreMarket, markets.AfterHours:
+			color = "#FFAF00"
+		default:
+			color = "#FF0000"
+		}
+		dot := session.Renderer.NewStyle().Foreground(lipgloss.Color(color)).Render("●")
+		parts = append(parts, fmt.Sprintf("%s %s %s", e.Region, dot, status))
+	}
+	return strings.Join(parts, "  ") + " "
+}
+
 func (m MainModel) GetKeys() []key.Binding {
 	return m.overlayManager.GetKeys()
 }
 
+// Stop the hub sending data to the session, called when the session ends.
+func (m MainModel) Close() {
+	m.feed.Close()
+}
+
 // Function to setup the application as an SSH server.
 func setupSSHServer(host string, port string, logFile *os.File) {
 	logOutput := io.MultiWriter(os.Stdout, logFile)
 	log.SetOutput(logOutput)
 
+	setupHub()
+
 	s, err := wish.NewServer(
 		wish.WithAddress(net.JoinHostPort(host, port)),
 		wish.WithHostKeyPath(".ssh/id_ed25519"),
+		// accept everyone, public keys are only used to find the user's saved state
+		wish.WithPublicKeyAuth(func(ctx ssh.Context, key ssh.PublicKey) bool { return true }),
+		wish.WithKeyboardInteractiveAuth(func(ctx ssh.Context, challenger gossh.KeyboardInteractiveChallenge) bool { return true }),
 		wish.WithMiddleware(
 			bubbleteaMiddleware(),
 			activeterm.Middleware(),
@@ -392,40 +438,87 @@ func main() {
 		setupSSHServer(host, port, logFile)
 	} else {
 		// TODO: Setup program without SSH
-		utils.UserLog = log.New(logFile)
-		utils.UserLog.SetOutput(logFile)
+		userLog := log.New(logFile)
+		userLog.SetOutput(logFile)
 		log.SetOutput(logFile)
 
-		var dash MappedModel = &views.Dashboard{
-			Name: "Dashboard A",
-		}
+		setupHub()
 
-		dashTab := &Tab{
-			name:  "Dashboard",
-			model: dash,
+		session := &utils.Session{
+			Renderer: lipgloss.DefaultRenderer(),
+			Log:      userLog,
+			Config:   utils.LoadConfig(),
+			State:    &utils.UserState{},
 		}
-
-		m := MainModel{
-			tabs:      []*Tab{dashTab},
-			activeTab: 0,
+		if statePath, err := utils.StatePath(""); err == nil {
+			session.LoadState(statePath)
+		} else {
+			userLog.Errorf("Cannot find state file path, changes won't be saved: %v", err)
 		}
 
-		utils.Program = tea.NewProgram(m)
-		utils.Program.Run()
+		m := newMainModel(session)
+
+		program := tea.NewProgram(m)
+		session.SetProgram(program)
+		program.Run()
 	}
 }
 
-// Custom middleware for bubbletea, sets the Program variable.
+// Load the process-wide config and start the market data hub and webhooks every session shares.
+func setupHub() {
+	config := utils.LoadConfig()
+	utils.ConfigureQuoteProvider(config)
+	markets.LoadHolidays(config)
+	var err error
+	if scraping.Cache, err = scraping.NewArticleCache(config); err != nil {
+		log.Errorf("Cannot open the article cache, articles will be scraped every time: %v", err)
+	}
+	webhook.Shared = webhook.New(config)
+	hub.Shared = hub.New(config)
+	hub.Shared.Webhooks = webhook.Shared
+}
+
+// Create the entry model and the tabs for a session.
+func newMainModel(session *utils.Session) MainModel {
+	// one subscription per session, the tabs share it
+	feed := hub.Shared.Subscribe(session)
+
+	var dash MappedModel = &views.Dashboard{
+		Name:    "Dashboard A",
+		Session: session,
+		Feed:    feed,
+	}
+
+	dashTab := &Tab{
+		name:  "Dashboard",
+		model: dash,
+	}
+
+	portfolioTab := &Tab{
+		name:  "Portfolio",
+		model: &views.Portfolio{Session: session, Feed: feed},
+	}
+
+	return MainModel{
+		session:   session,
+		feed:      feed,
+		tabs:      []*Tab{dashTab, portfolioTab},
+		activeTab: 0,
+		input: Prompt{
+			Model: textinput.New(),
+		},
+	}
+}
+
+// Custom middleware for bubbletea, gives every connection its own session.
 func bubbleteaMiddleware() wish.Middleware {
 	log.Info("Starting middleware")
-	newProg := func(m tea.Model, opts []tea.ProgramOption) *tea.Program {
-		p := tea.NewProgram(m, opts...)
-		utils.Program = p
-		return p
-	}
-	log.Info("bubbletea program created")
 	teaHandler := func(s ssh.Session) *tea.Program {
-		return newProg(setupSSHApplication(s))
+		session, m, opts := setupSSHApplication(s)
+		program := tea.NewProgram(m, opts...)
+		session.SetProgram(program)
+		log.Info("bubbletea program created")
+		return program
 	}
 
 	return bm.MiddlewareWithProgramHandler(teaHandler, termenv.Ascii)
@@ -433,15 +526,12 @@ func bubbleteaMiddleware() wish.Middleware {
 }
 
 // Setup bubletea model to work with Wish
-func setupSSHApplication(s ssh.Session) (tea.Model, []tea.ProgramOption) {
+func setupSSHApplication(s ssh.Session) (*utils.Session, tea.Model, []tea.ProgramOption) {
 	log.Info("setupBubbleTea")
 	userString := fmt.Sprintf("%s.%s", s.User(), strings.Split(s.RemoteAddr().String(), ":")[0])
 	log.Infof("Connection from %s", userString)
 	// pty, _, _ := s.Pty()
 
-	// use instead of lipgloss.NewStyle()
-	utils.Renderer = bubbletea.MakeRenderer(s)
-
 	// CREATE USER LOGGER
 	/* BUG: File closes after function ends,
 	making logging impossible after end of function
@@ -467,33 +557,42 @@ func setupSSHApplication(s ssh.Session) (tea.Model, []tea.ProgramOption) {
 		log.Error("Cannot create log file", err)
 	}
 
-	utils.UserLog = log.New(f)
+	userLog := log.New(f)
 	// NOTE: Setting time format doesn't work, figure out how to fix this later.
-	utils.UserLog.SetTimeFormat("2006/01/02 15:04:05")
-	utils.UserLog.Info("User log created")
+	userLog.SetTimeFormat("2006/01/02 15:04:05")
+	userLog.Info("User log created")
+
+	session := &utils.Session{
+		Renderer: bubbletea.MakeRenderer(s),
+		Log:      userLog,
+		Config:   utils.LoadConfig(),
+		State:    &utils.UserState{},
+	}
+
+	// state is stored per public key, users without one don't get saved state
+	if key := s.PublicKey(); key != nil {
+		fingerprint := fmt.Sprintf("%x", sha256.Sum256(key.Marshal()))
+		if statePath, err := utils.StatePath(fingerprint); err == nil {
+			session.LoadState(statePath)
+		} else {
+			userLog.Errorf("Cannot find state file path, changes won't be saved: %v", err)
+		}
+	} else {
+		userLog.Info("No public key for this connection, state won't be saved")
+	}
+
+	m := newMainModel(session)
 
 	// This function runs on
 	go func() {
 		<-s.Context().Done()
-		utils.UserLog.Info("Connection closed, ending file.")
+		// stop the hub from sending data to this session
+		m.Close()
+		userLog.Info("Connection closed, ending file.")
 		if err := f.Close(); err != nil {
 			log.Error("Error closing log file", "error", err)
 		}
 	}()
 
-	var dash MappedModel = &views.Dashboard{
-		Name: "Dashboard A",
-	}
-
-	dashTab := &Tab{
-		name:  "Dashboard",
-		model: dash,
-	}
-
-	m := MainModel{
-		tabs:      []*Tab{dashTab},
-		activeTab: 0,
-	}
-
-	return m, []tea.ProgramOption{tea.WithAltScreen(), tea.WithInput(s), tea.WithOutput(s)}
+	return session, m, []tea.ProgramOption{tea.WithAltScreen(), tea.WithInput(s), tea.WithOutput(s)}
 }
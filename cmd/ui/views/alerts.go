@@ -0,0 +1,119 @@
+package views
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"gloomberg/cmd/ui/components"
+	"gloomberg/internal/alerts"
+	"gloomberg/internal/utils"
+	"gloomberg/internal/webhook"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Create an alert on target from the text typed in the prompt.
+type addAlertMsg struct {
+	Target string
+	Text   string
+	// whether the target is a commodity, only commodities have 7D moves
+	Commodity bool
+}
+
+// Open a prompt to create an alert on the focused row of the commodity or stock table.
+func (d *Dashboard) promptAlert() tea.Cmd {
+	var target string
+	var commodity bool
+	switch d.focused {
+	case 0:
+		cursor := d.tables[0].Cursor()
+		if cursor < 0 || cursor >= len(d.commodities) {
+			return nil
+		}
+		target = d.commodities[cursor].Name
+		commodity = true
+	case 1:
+		row, ok := d.selectedStockRow()
+		if !ok || row.Header {
+			return nil
+		}
+		target = row.Symbol
+	default:
+		return nil
+	}
+
+	return func() tea.Msg {
+		return utils.PromptOpenMsg{
+			Prompt: fmt.Sprintf("Alert on %s (e.g. above 200, move 5, 7d below -3): ", target),
+			CallbackFunc: func(s string) tea.Msg {
+				return addAlertMsg{Target: target, Text: s, Commodity: commodity}
+			},
+		}
+	}
+}
+
+func (d *Dashboard) addAlert(msg addAlertMsg) tea.Cmd {
+	alert, err := alerts.Parse(msg.Target, msg.Text)
+	if err == nil && alert.Metric == alerts.SevenDay && !msg.Commodity {
+		err = fmt.Errorf("7D moves are only available for commodities")
+	}
+	if err != nil {
+		d.Session.Log.Warnf("Invalid alert %q: %v", msg.Text, err)
+		return func() tea.Msg {
+			return utils.SendNotificationMsg{
+				Message:     fmt.Sprintf("Invalid alert: %s", err),
+				DisplayTime: 4000,
+			}
+		}
+	}
+
+	d.Session.State.Alerts = append(d.Session.State.Alerts, alert)
+	d.Session.SaveState()
+	d.Session.Log.Infof("Created alert %s", alert)
+	return func() tea.Msg {
+		return utils.SendNotificationMsg{
+			Message:     fmt.Sprintf("Alert set: %s", alert),
+			DisplayTime: 3000,
+		}
+	}
+}
+
+// Open the overlay listing every alert.
+func (d *Dashboard) showAlerts() tea.Cmd {
+	list := components.AlertList{
+		Session: d.Session,
+		Width:   d.width / 2,
+		Height:  int(float64(d.height) * .8),
+	}
+	return func() tea.Msg { return DisplayOverlayMsg(&list) }
+}
+
+// Check the alerts against new data, alerts that go off raise a notification.
+func (d *Dashboard) checkAlerts(observations []alerts.Observation) tea.Cmd {
+	fired := alerts.Evaluate(
+		d.Session.State.Alerts,
+		observations,
+		time.Now(),
+		d.Session.Config.Duration("alerts.cooldown"),
+	)
+	if len(fired) == 0 {
+		return nil
+	}
+
+	// LastFired changed, save it so the cooldown survives a restart
+	d.Session.SaveState()
+
+	var messages []string
+	for _, f := range fired {
+		d.Session.Log.Infof("Alert fired: %s", f)
+		messages = append(messages, f.String())
+		webhook.Shared.Alert(f)
+	}
+	return func() tea.Msg {
+		return utils.SendNotificationMsg{
+			Message:     fmt.Sprintf("󰂞 %s", strings.Join(messages, "; ")),
+			DisplayTime: 5000,
+		}
+	}
+}
@@ -3,10 +3,11 @@ package views
 import (
 	"fmt"
 	"math"
-	"strconv"
-	"time"
 
 	"gloomberg/cmd/ui/components"
+	"gloomberg/internal/alerts"
+	"gloomberg/internal/hub"
+	"gloomberg/internal/llm"
 	"gloomberg/internal/scraping"
 	"gloomberg/internal/utils"
 
@@ -17,62 +18,31 @@ import (
 	tea "github.com/charmbracelet/bubbletea"
 )
 
-func commodityUpdateTick() tea.Cmd {
-	return tea.Tick(5*time.Second, func(t time.Time) tea.Msg {
-		return scraping.GetCommodities()
-	})
-}
-
-// Update the stock prices every 5 seconds.
-func stockUpdateTick(symbols []string) tea.Cmd {
-	utils.UserLog.Info("stockUpdateTick")
-	return tea.Tick(5*time.Second, func(t time.Time) tea.Msg {
-		var rows []RowData
-		for _, symbol := range symbols {
-			q, err := utils.GetCurrentOHLCV(symbol)
-			if err != nil {
-				utils.UserLog.Errorf("Error fetching data for %s: %v", symbol, err)
-				continue
-			}
-			row := RowData{
-				CompanyName:   q.ShortName,
-				Symbol:        q.Symbol,
-				Price:         q.RegularMarketPrice,
-				PercentChange: q.RegularMarketChange,
-				SMA:           q.Quote.FiftyDayAverage,
-			}
-			rows = append(rows, row)
-		}
-		return WatchlistUpdateMsg{Rows: rows, Refresh: true}
-	})
-}
-
-func (d *Dashboard) GetWatchList(refresh bool) tea.Msg {
+// Convert quotes from the hub into watchlist rows. Symbols that failed to
+// load are returned as stale rows without data, the dashboard fills them in
+// with the last known data for that symbol.
+func rowsFromQuotes(results hub.QuoteUpdateMsg) []RowData {
 	var rows []RowData
-	for _, symbol := range d.WatchList {
-		utils.UserLog.Infof("Getting data for %s", symbol)
-		q, err := utils.GetCurrentOHLCV(symbol)
-		if err != nil {
-			utils.UserLog.Errorf("Error fetching data for %s: %v", symbol, err)
+	for _, result := range results {
+		if result.Err != nil {
+			rows = append(rows, RowData{Symbol: result.Symbol, Stale: true, Err: result.Err})
 			continue
 		}
-		row := RowData{
+		q := result.Quote
+		rows = append(rows, RowData{
 			CompanyName:   q.ShortName,
-			Symbol:        q.Symbol,
-			Price:         q.RegularMarketPrice,
-			PercentChange: q.RegularMarketChange,
-			SMA:           q.Quote.FiftyDayAverage,
-		}
-		rows = append(rows, row)
+			Symbol:        result.Symbol,
+			Price:         q.Price,
+			PercentChange: q.ChangePercent,
+			SMA:           q.FiftyDayAverage,
+		})
 	}
-	return WatchlistUpdateMsg{Rows: rows, Refresh: refresh}
+	return rows
 }
 
+// New data for some or all of the symbols in the watchlist.
 type WatchlistUpdateMsg struct {
 	Rows []RowData
-	// Should recieving this WatchlistUpdateMsg
-	// send another WatchlistUpdateMsg in a couple of seconds?
-	Refresh bool
 }
 
 type RowData struct {
@@ -81,6 +51,10 @@ type RowData struct {
 	Price         float64
 	PercentChange float64
 	SMA           float64
+	// Whether the last fetch for this symbol failed and the data is old
+	Stale bool
+	// why the last fetch failed, only set on stale rows
+	Err error
 }
 
 // Return a table.Row for the stock table to use
@@ -91,8 +65,12 @@ func (d RowData) Render() table.Row {
 	} else {
 		color = "\033[38;5;196m" // red
 	}
+	var staleMarker string
+	if d.Stale {
+		staleMarker = " (stale)"
+	}
 	return table.Row{
-		fmt.Sprintf("%s%s (%s)", color, d.CompanyName, d.Symbol),
+		fmt.Sprintf("%s%s (%s)%s", color, d.CompanyName, d.Symbol, staleMarker),
 		fmt.Sprintf("$%.2f", d.SMA),
 		fmt.Sprintf("$%.2f", d.Price),
 		// NOTE: Adding return-to-normal escape code (\033[0m) breaks table width, doesn't matter though,
@@ -103,13 +81,17 @@ func (d RowData) Render() table.Row {
 
 type DisplayOverlayMsg tea.Model
 
+// Add a symbol to the watchlist.
+type AddSymbolMsg string
+
 type TableStyle struct {
 	innerStyle table.Styles
 	outerStyle lipgloss.Style
 }
 
 type Dashboard struct {
-	Name string
+	Name    string
+	Session *utils.Session
 	// screen height
 	height int
 	// screen width
@@ -122,16 +104,44 @@ type Dashboard struct {
 	focusedStyle TableStyle
 	// focused style
 	unfocusedStyle TableStyle
-	// map the row in the table to an actual news article
-	articleMap map[int]scraping.NewsArticle
-
-	// Stock watchlist
-	WatchList []string
+	// the commodities shown in the commodity table, in the same order
+	commodities []scraping.Commodity
+	// every article in the news table, keyed by article ID
+	articleMap map[string]scraping.NewsArticle
+	// IDs of articles that arrived after the first news update and haven't been opened
+	unseen map[string]bool
+	// whether the first news update came in
+	newsLoaded bool
+	// filter applied to the news table
+	filter scraping.NewsFilter
+	// error from the filter being typed, the last valid filter stays applied
+	filterErr error
+	// name of the saved view the filter came from, empty if none
+	view string
+	// questions asked about articles this session, by article ID
+	chats map[string][]llm.Message
+
+	// Every watchlist the user has
+	watchLists []*utils.Watchlist
+	// the watchlist shown in the stock table, one of watchLists
+	WatchList *utils.Watchlist
+	// what each row of the stock table shows, in the same order as the table
+	stockRows []stockRow
+	// last successfully fetched row for each symbol, shown when a fetch fails
+	lastRows map[string]RowData
+	// why symbols that have never loaded failed to, e.g. a mistyped ticker
+	quoteErrs map[string]error
+	// the session's subscription to the market data hub, shared with the other tabs
+	Feed *hub.Subscription
 }
 
 func (d *Dashboard) Init() tea.Cmd {
 	// make article map
-	d.articleMap = make(map[int]scraping.NewsArticle)
+	d.articleMap = make(map[string]scraping.NewsArticle)
+	d.unseen = make(map[string]bool)
+	d.lastRows = make(map[string]RowData)
+	d.quoteErrs = make(map[string]error)
+	d.chats = make(map[string][]llm.Message)
 
 	cmdtyTable := table.New(
 		table.WithFocused(false),
@@ -140,35 +150,35 @@ func (d *Dashboard) Init() tea.Cmd {
 
 	newsTable := table.New(table.WithFocused(false))
 
-	accentColor := utils.Koanf.String("theme.accentColor")
+	accentColor := d.Session.Config.String("theme.accentColor")
 
 	foucsedInnerStyle := table.Styles{
-		Header: utils.Renderer.NewStyle().
+		Header: d.Session.Renderer.NewStyle().
 			Align(lipgloss.Center).
 			Bold(true).
 			Foreground(lipgloss.Color("#FFFFFF")),
-		Cell:     utils.Renderer.NewStyle(),
-		Selected: utils.Renderer.NewStyle().Bold(true).Foreground(lipgloss.Color(accentColor)),
+		Cell:     d.Session.Renderer.NewStyle(),
+		Selected: d.Session.Renderer.NewStyle().Bold(true).Foreground(lipgloss.Color(accentColor)),
 	}
 
 	unfocusedInnerStyle := table.Styles{
-		Header: utils.Renderer.NewStyle().
+		Header: d.Session.Renderer.NewStyle().
 			BorderForeground(lipgloss.Color(accentColor)).
 			Bold(false),
-		Cell:     utils.Renderer.NewStyle(),
-		Selected: utils.Renderer.NewStyle().Bold(true).Foreground(lipgloss.Color(accentColor)),
+		Cell:     d.Session.Renderer.NewStyle(),
+		Selected: d.Session.Renderer.NewStyle().Bold(true).Foreground(lipgloss.Color(accentColor)),
 	}
 
 	d.focusedStyle = TableStyle{
 		innerStyle: foucsedInnerStyle,
-		outerStyle: utils.Renderer.NewStyle().
+		outerStyle: d.Session.Renderer.NewStyle().
 			BorderForeground(lipgloss.Color(accentColor)).
 			Border(lipgloss.NormalBorder()),
 	}
 
 	d.unfocusedStyle = TableStyle{
 		innerStyle: unfocusedInnerStyle,
-		outerStyle: utils.Renderer.NewStyle().BorderForeground(lipgloss.Color("#FFFFFF")).Border(lipgloss.NormalBorder()),
+		outerStyle: d.Session.Renderer.NewStyle().BorderForeground(lipgloss.Color("#FFFFFF")).Border(lipgloss.NormalBorder()),
 	}
 
 	d.tables = append(d.tables, cmdtyTable, stockTable, newsTable)
@@ -180,119 +190,141 @@ func (d *Dashboard) Init() tea.Cmd {
 
 	d.tables[0].Focus()
 
-	d.WatchList = utils.Koanf.Strings("dashboard.tickers")
-	return tea.Batch(scraping.GetCommodities,
-		scraping.GetAllNews,
-		func() tea.Msg { return commodityUpdateTick() },
-		func() tea.Msg { return d.GetWatchList(true) },
-	)
-}
+	// watchlists saved in the user's state take over from the config
+	d.watchLists = d.Session.State.MergeWatchlists(utils.WatchlistsFromConfig(d.Session.Config))
+	d.WatchList = d.watchLists[0]
+	for _, list := range d.watchLists {
+		if list.Name == d.Session.State.ActiveWatchlist {
+			d.WatchList = list
+		}
+	}
+	d.resizeTables()
 
-func (d *Dashboard) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
-	var cmd tea.Cmd
-	switch msg := msg.(type) {
-	case tea.WindowSizeMsg:
-		d.width = msg.Width
-		d.height = msg.Height - 1
+	// commodities, news and quotes are all pushed to us by the hub
+	d.Feed.Watch(d.WatchList.Symbols()...)
+	return nil
+}
 
-		// Redraw tables
+// Write the watchlist to the user's state file.
+func (d *Dashboard) saveWatchList() {
+	d.Session.State.SetWatchlist(d.WatchList)
+	d.Session.SaveState()
+}
 
-		// NOTE: For some reason using exactly 1/2 the width and 2/3 the screen
-		// draws the border past it's boundaries. whatever make it slightly less
-		topTablesWidth := int(float64(d.width) * .49)
-		topTablesHeight := int(float64(d.height) * .65)
+// Size the tables to the screen. Also called from Init, so the tables have
+// columns if data arrives before the first resize.
+func (d *Dashboard) resizeTables() {
+	// NOTE: For some reason using exactly 1/2 the width and 2/3 the screen
+	// draws the border past it's boundaries. whatever make it slightly less
+	topTablesWidth := int(float64(d.width) * .49)
+	topTablesHeight := int(float64(d.height) * .65)
+
+	d.tables[0].SetWidth(topTablesWidth)
+	d.tables[1].SetWidth(topTablesWidth)
+
+	d.tables[0].SetHeight(topTablesHeight)
+	d.tables[1].SetHeight(topTablesHeight)
+
+	// The width of the Commodity COLUMN.
+	cmdtyColumnWidth := int(float64(topTablesWidth) * 1 / 2)
+	// the width of the 5d, 1d, and current price column
+	priceMovementColumnWidth := topTablesWidth - cmdtyColumnWidth
+	cmdtyTableColumns := []table.Column{
+		{Title: "Commodity", Width: cmdtyColumnWidth},
+		{Title: "1D", Width: int(float64(priceMovementColumnWidth) * 1 / 3)},
+		{Title: "7D", Width: int(float64(priceMovementColumnWidth) * 1 / 3)},
+		{Title: "Price", Width: int(float64(priceMovementColumnWidth) * 1 / 3)},
+	}
 
-		d.tables[0].SetWidth(topTablesWidth)
-		d.tables[1].SetWidth(topTablesWidth)
+	d.tables[0].SetColumns(cmdtyTableColumns)
 
-		d.tables[0].SetHeight(topTablesHeight)
-		d.tables[1].SetHeight(topTablesHeight)
+	stockColumns := []table.Column{
+		{Title: d.watchListTitle(), Width: int(float64(topTablesWidth) * .4)},
+		{Title: "SMA (50d)", Width: int(float64(topTablesWidth) * .15)},
+		{Title: "Price", Width: int(float64(topTablesWidth) * .15)},
+		{Title: "%", Width: int(float64(topTablesWidth) * .1)},
+		{Title: "News", Width: int(float64(topTablesWidth) * .2)},
+	}
 
-		// The width of the Commodity COLUMN.
-		cmdtyColumnWidth := int(float64(topTablesWidth) * 1 / 2)
-		// the width of the 5d, 1d, and current price column
-		priceMovementColumnWidth := topTablesWidth - cmdtyColumnWidth
-		cmdtyTableColumns := []table.Column{
-			{Title: "Commodity", Width: cmdtyColumnWidth},
-			{Title: "1D", Width: int(float64(priceMovementColumnWidth) * 1 / 3)},
-			{Title: "7D", Width: int(float64(priceMovementColumnWidth) * 1 / 3)},
-			{Title: "Price", Width: int(float64(priceMovementColumnWidth) * 1 / 3)},
-		}
+	d.tables[1].SetColumns(stockColumns)
 
-		d.tables[0].SetColumns(cmdtyTableColumns)
+	newsTableWidth := topTablesWidth * 2
+	newsColumns := []table.Column{
+		{Title: "Headline", Width: int(math.Ceil(float64(newsTableWidth) * .6))},
+		{Title: "Tickers", Width: int(math.Ceil(float64(newsTableWidth) * .1))},
+		{Title: "Source", Width: int(math.Ceil(float64(newsTableWidth) * .12))},
+		{Title: "Date", Width: int(math.Ceil(float64(newsTableWidth) * .1))},
+		{Title: "Sentiment", Width: int(math.Ceil(float64(newsTableWidth) * .08))},
 
-		stockColumns := []table.Column{
-			{Title: "Symbol", Width: int(float64(topTablesWidth) * 1 / 2)},
-			{Title: "SMA (50d)", Width: int(float64(topTablesWidth) * 2 / 10)},
-			{Title: "Price", Width: int(float64(topTablesWidth) * 2 / 10)},
-			{Title: "%", Width: int(float64(topTablesWidth) * 1 / 10)},
-		}
+		{Title: "index", Width: 0},
+	}
 
-		d.tables[1].SetColumns(stockColumns)
+	d.tables[2].SetColumns(newsColumns)
+	d.tables[2].SetWidth(newsTableWidth)
+	d.tables[2].SetHeight(d.height - topTablesHeight - 5)
+}
 
-		newsTableWidth := topTablesWidth * 2
-		newsColumns := []table.Column{
-			{Title: "Headline", Width: int(math.Ceil(float64(newsTableWidth) * .75))},
-			{Title: "Source", Width: int(math.Ceil(float64(newsTableWidth) * .125))},
-			{Title: "Date", Width: int(math.Ceil(float64(newsTableWidth) * .125))},
+func (d *Dashboard) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	var cmd tea.Cmd
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		d.width = msg.Width
+		d.height = msg.Height - 1
+		d.resizeTables()
 
-			{Title: "index", Width: 0},
+	case tea.KeyMsg:
+		// keys for editing the watchlist take priority over the table's own keys
+		if d.focused == 1 {
+			if handled, cmd := d.editWatchList(msg); handled {
+				return d, cmd
+			}
 		}
 
-		d.tables[2].SetColumns(newsColumns)
-		d.tables[2].SetWidth(newsTableWidth)
-		d.tables[2].SetHeight(d.height - topTablesHeight - 5)
-
-	case tea.KeyMsg:
 		switch msg.String() {
 		case "tab":
-			// unfocus currently focused table
 			// BUG: Can't fit everything into table
-			d.tables[d.focused].Blur()
-			if d.focused < len(d.tables)-1 {
-				d.tables[d.focused].SetStyles(d.unfocusedStyle.innerStyle)
-				d.focused += 1
-			} else {
-				d.tables[d.focused].SetStyles(d.unfocusedStyle.innerStyle)
-				d.focused = 0
-			}
-			d.tables[d.focused].Focus()
-			d.tables[d.focused].SetStyles(d.focusedStyle.innerStyle)
-
-			utils.UserLog.Infof("Focusing on table %v", d.focused)
+			d.focusTable((d.focused + 1) % len(d.tables))
 		case "shift+tab":
-			d.tables[d.focused].Blur()
-			if d.focused > 0 {
-				d.tables[d.focused].SetStyles(d.unfocusedStyle.innerStyle)
-				d.focused -= 1
-			} else {
-				d.tables[d.focused].SetStyles(d.unfocusedStyle.innerStyle)
-				d.focused = len(d.tables) - 1
-			}
-			d.tables[d.focused].Focus()
-			d.tables[d.focused].SetStyles(d.focusedStyle.innerStyle)
-
-			utils.UserLog.Infof("Focusing on table %v", d.focused)
+			d.focusTable((d.focused + len(d.tables) - 1) % len(d.tables))
 
 		case "enter":
-			utils.UserLog.Info("enter pressed")
+			d.Session.Log.Info("enter pressed")
 
 			switch d.focused {
 			// different actions depending on which table is focused
+			case 1: // stock table
+				return d, d.filterNewsBySymbol()
 			case 2: // news table
-				rowID, err := strconv.Atoi(d.tables[2].SelectedRow()[3]) // index of the article in the articleMap
-				if err != nil {
-					utils.UserLog.Fatal(err)
+				selectedStory, ok := d.selectedArticle()
+				if !ok {
+					return d, nil
 				}
-				selectedStory := d.articleMap[rowID]
-				newsOverlay := components.NewsModal{
-					Article: &selectedStory,
-					W:       d.width / 2,
-					H:       int(float64(d.height) * .8),
-				}
-				return d, func() tea.Msg { return (&newsOverlay) }
+				return d, d.openArticle(selectedStory)
 
 			}
+		case "M":
+			if d.focused == 2 {
+				d.markSeen("")
+			}
+		case "/":
+			return d, d.promptNewsFilter()
+		case "V":
+			if d.focused == 2 {
+				return d, d.cycleNewsView()
+			}
+		case "v":
+			if d.focused == 2 {
+				return d, d.promptSaveNewsView()
+			}
+		case "A":
+			// create an alert on the focused commodity or stock
+			return d, d.promptAlert()
+		case "L":
+			return d, d.showAlerts()
+		case "S":
+			return d, d.showSources()
+		case "B":
+			return d, d.showBrief()
 		case "a":
 			// add symbol on stock table
 			if d.focused == 1 {
@@ -302,19 +334,12 @@ func (d *Dashboard) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 						CallbackFunc: func(s string) tea.Msg {
 							// TODO: Create an overlay for the current search query.
 							stocklist := components.CommoditySuggestions{
+								Session:     d.Session,
 								SearchQuery: s,
 								Width:       d.width / 2,
 								Height:      int(float64(d.height) * .8),
 								CallbackFunc: func(s components.Suggestion) tea.Msg {
-									// TODO: Create a function that can add to the watchlist
-									// and refresh the display, instead of waiting for the next
-									// update message.
-									d.WatchList = append(d.WatchList, s.Symbol)
-									utils.Program.Send(d.GetWatchList(false))
-									return utils.SendNotificationMsg{
-										Message:     fmt.Sprintf("Adding $%s to watchlist", s.Symbol),
-										DisplayTime: 3000,
-									}
+									return AddSymbolMsg(s.Symbol)
 								},
 							}
 							return DisplayOverlayMsg(&stocklist)
@@ -326,7 +351,7 @@ func (d *Dashboard) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		d.tables[d.focused], cmd = d.tables[d.focused].Update(msg)
 
 	case scraping.CommodityUpdateMsg:
-		utils.UserLog.Info("Commodity Data Recieved")
+		d.Session.Log.Info("Commodity Data Recieved")
 		rows := []table.Row{}
 		for _, cmdty := range msg {
 			var color string
@@ -342,62 +367,121 @@ func (d *Dashboard) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			})
 		}
 		d.tables[0].SetRows(rows)
-		utils.UserLog.Info("Got commodity data")
-		return d, commodityUpdateTick()
+		d.commodities = msg
+		d.Session.Log.Info("Got commodity data")
 
-	case scraping.NewsUpdate:
-		utils.UserLog.Info("Got news update")
+		var observations []alerts.Observation
+		for _, cmdty := range msg {
+			observations = append(observations, alerts.Observation{
+				Target:      cmdty.Name,
+				Price:       cmdty.Price,
+				OneDay:      cmdty.OneDayMovement,
+				SevenDay:    cmdty.WeeklyMovement,
+				HasSevenDay: true,
+			})
+		}
+		cmd = d.checkAlerts(observations)
 
-		rows := []table.Row{}
+	case newsFilterMsg:
+		cmd = d.applyNewsFilter(msg)
 
-		for i, article := range msg {
-			// Format the publication date
-			var formattedTime string
+	case saveNewsViewMsg:
+		cmd = d.saveNewsView(string(msg))
 
-			year, month, day := article.PublicationDate.Date()
-			nowYear, nowMonth, nowDay := time.Now().Date()
-			if year == nowYear && month == nowMonth && day == nowDay {
-				// If the article was published today, format it as HH:MM AM/PM
-				formattedTime = article.PublicationDate.Format("03:04 PM")
-			} else {
-				// Otherwise, format it as MM/DD
-				formattedTime = article.PublicationDate.Format("01/02")
-			}
+	case scraping.NewsUpdate:
+		d.Session.Log.Info("Got news update")
+		cmd = d.mergeNews(msg)
 
-			var flaggedTitle string // the title with a flag to show whether or not it's readable
-			if article.Readable {
-				flaggedTitle = fmt.Sprintf("%s %s", "", article.Title)
-			} else {
-				flaggedTitle = article.Title
-			}
+	case components.UpdateContentMsg:
+		d.markScraped(scraping.NewsArticle(msg))
 
-			newsRow := table.Row{
-				flaggedTitle,
-				article.Source,
-				formattedTime,
-				strconv.Itoa(i), // index in articleMap (as a string)
+	case components.OpenArticleMsg:
+		article, ok := d.articleMap[string(msg)]
+		if !ok {
+			return d, nil
+		}
+		// the overlay linking to the article is closed first
+		return d, tea.Sequence(func() tea.Msg { return utils.ModalCloseMsg(true) }, d.openArticle(article))
+
+	case AddSymbolMsg:
+		symbol := string(msg)
+		if !d.WatchList.Add(symbol) {
+			return d, func() tea.Msg {
+				return utils.SendNotificationMsg{
+					Message:     fmt.Sprintf("$%s is already in the watchlist", symbol),
+					DisplayTime: 3000,
+				}
+			}
+		}
+		d.saveWatchList()
+		d.renderStockTable()
+		d.Feed.Watch(symbol)
+		return d, func() tea.Msg {
+			return utils.SendNotificationMsg{
+				Message:     fmt.Sprintf("Adding $%s to watchlist", symbol),
+				DisplayTime: 3000,
 			}
+		}
 
-			d.articleMap[i] = article
+	case addAlertMsg:
+		return d, d.addAlert(msg)
 
-			rows = append(rows, newsRow)
-		}
-		d.tables[2].SetRows(rows)
+	case setGroupMsg:
+		d.WatchList.SetGroup(msg.Symbol, msg.Group)
+		d.saveWatchList()
+		d.renderStockTable()
+
+	case hub.QuoteUpdateMsg:
+		return d.Update(WatchlistUpdateMsg{Rows: rowsFromQuotes(msg)})
 
 	case WatchlistUpdateMsg:
-		utils.UserLog.Info("Got stock data (WatchlistUpdateMsg)")
-		var tableRows []table.Row
+		d.Session.Log.Info("Got stock data (WatchlistUpdateMsg)")
+		var observations []alerts.Observation
 		for _, row := range msg.Rows {
-			tableRows = append(tableRows, row.Render())
-			utils.UserLog.Infof("Adding row for %s", row.Symbol)
+			// the subscription also carries quotes for the other tabs, e.g. the portfolio's
+			if !d.WatchList.Contains(row.Symbol) {
+				continue
+			}
+			if !row.Stale {
+				observations = append(observations, alerts.Observation{
+					Target: row.Symbol,
+					Price:  row.Price,
+					OneDay: row.PercentChange,
+				})
+			}
+			if row.Stale {
+				last, ok := d.lastRows[row.Symbol]
+				if !ok {
+					// a symbol that has never loaded can only show why
+					d.quoteErrs[row.Symbol] = row.Err
+					continue
+				}
+				last.Stale = true
+				row = last
+			}
+			d.lastRows[row.Symbol] = row
+			delete(d.quoteErrs, row.Symbol)
 		}
-		d.tables[1].SetRows(tableRows)
-		return d, stockUpdateTick(d.WatchList)
+
+		// updates can cover only part of the watchlist, so always redraw all of it
+		d.renderStockTable()
+		cmd = d.checkAlerts(observations)
 	}
 
 	return d, cmd
 }
 
+// Move focus to tables[i].
+func (d *Dashboard) focusTable(i int) {
+	d.tables[d.focused].Blur()
+	d.tables[d.focused].SetStyles(d.unfocusedStyle.innerStyle)
+	d.focused = i
+	d.tables[d.focused].Focus()
+	d.tables[d.focused].SetStyles(d.focusedStyle.innerStyle)
+
+	d.Session.Log.Infof("Focusing on table %v", d.focused)
+}
+
 func (d *Dashboard) GetKeys() []key.Binding { // TODO: Change to have actual type safety
 	keyList := []key.Binding{
 		key.NewBinding(
@@ -415,17 +499,46 @@ func (d *Dashboard) GetKeys() []key.Binding { // TODO: Change to have actual typ
 
 	// FIXME: This does not work, I'm assuming I have to send an Update 🙄.
 	// There should be vue-type reactive data
+	if d.focused == 0 || d.focused == 1 {
+		keyList = append(keyList, key.NewBinding(
+			key.WithKeys("A"),
+			key.WithHelp("A", "Add Alert"),
+		))
+	}
+	keyList = append(keyList, key.NewBinding(
+		key.WithKeys("L"),
+		key.WithHelp("L", "Alerts"),
+	))
+	keyList = append(keyList, key.NewBinding(
+		key.WithKeys("S"),
+		key.WithHelp("S", "Sources"),
+	))
+	keyList = append(keyList, key.NewBinding(
+		key.WithKeys("B"),
+		key.WithHelp("B", "Brief"),
+	))
+
 	if d.focused == 1 {
 		keyList = append(keyList, key.NewBinding(
 			key.WithHelp("a", "Add Stock"),
 			key.WithKeys("a", "add"),
 		))
+		keyList = append(keyList, watchListKeys...)
+		keyList = append(keyList, key.NewBinding(
+			key.WithHelp("<enter>", "Show news"),
+			key.WithKeys("enter"),
+		))
 	}
-	if d.focused == 3 {
+	if d.focused == 2 {
 		keyList = append(keyList, key.NewBinding(
 			key.WithHelp("<enter>", "Read article"),
 			key.WithKeys("enter", "select"),
 		))
+		keyList = append(keyList, key.NewBinding(
+			key.WithHelp("M", "Mark all read"),
+			key.WithKeys("M"),
+		))
+		keyList = append(keyList, newsFilterKeys...)
 
 	}
 
@@ -433,17 +546,22 @@ func (d *Dashboard) GetKeys() []key.Binding { // TODO: Change to have actual typ
 }
 
 func (d *Dashboard) View() string {
-	accentColor := utils.Koanf.String("theme.accentColor")
+	accentColor := d.Session.Config.String("theme.accentColor")
 
-	foucsedBorder := utils.Renderer.NewStyle().Border(lipgloss.NormalBorder()).BorderForeground(lipgloss.Color(accentColor))
-	unfocusedBorder := utils.Renderer.NewStyle().Border(lipgloss.NormalBorder())
+	foucsedBorder := d.Session.Renderer.NewStyle().Border(lipgloss.NormalBorder()).BorderForeground(lipgloss.Color(accentColor))
+	unfocusedBorder := d.Session.Renderer.NewStyle().Border(lipgloss.NormalBorder())
 
 	var styledTables []string
-	for _, t := range d.tables {
+	for i, t := range d.tables {
+		border := unfocusedBorder
 		if t.Focused() {
-			styledTables = append(styledTables, foucsedBorder.Render(t.View()))
+			border = foucsedBorder
+		}
+		if i == 2 {
+			// the news table shows the active filter in its border
+			styledTables = append(styledTables, borderWithTitle(border, d.Session.Renderer, t.View(), d.newsTitle()))
 		} else {
-			styledTables = append(styledTables, unfocusedBorder.Render(t.View()))
+			styledTables = append(styledTables, border.Render(t.View()))
 		}
 	}
 
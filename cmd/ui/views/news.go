@@ -0,0 +1,331 @@
+package views
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"gloomberg/cmd/ui/components"
+	"gloomberg/internal/scraping"
+	"gloomberg/internal/utils"
+
+	"github.com/charmbracelet/bubbles/table"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Column of the news table holding the article's ID in articleMap.
+const newsIDColumn = 5
+
+// Merge freshly fetched articles into the news table. Articles that weren't
+// there before are marked unseen, except on the first update.
+func (d *Dashboard) mergeNews(news scraping.NewsUpdate) tea.Cmd {
+	// cluster again with the articles we already have, so stories picked up by
+	// another source join their existing row instead of showing up as new
+	// fresh articles come first so their tags win over the ones we have
+	existing := d.sortedArticles()
+	scraped := make(map[string]scraping.NewsArticle)
+	for _, story := range existing {
+		for _, article := range append([]scraping.NewsArticle{story}, story.Duplicates...) {
+			if article.Readable {
+				scraped[article.ID()] = article
+			}
+		}
+	}
+	fresh := slices.Clone(news)
+	for i := range fresh {
+		carryScraped(&fresh[i], scraped)
+		fresh[i].Duplicates = slices.Clone(fresh[i].Duplicates)
+		for j := range fresh[i].Duplicates {
+			carryScraped(&fresh[i].Duplicates[j], scraped)
+		}
+	}
+
+	all := append(fresh, existing...)
+	stories := scraping.ClusterArticles(all, d.Session.Config.Float64("news.dedup_threshold"))
+
+	articles := make(map[string]scraping.NewsArticle, len(stories))
+	var added int
+	for _, story := range stories {
+		story = d.keepStoryID(story)
+		id := story.ID()
+		if _, ok := d.articleMap[id]; !ok {
+			added++
+			if d.newsLoaded {
+				d.unseen[id] = true
+			}
+		}
+		articles[id] = story
+	}
+	d.articleMap = articles
+	for id := range d.unseen {
+		if _, ok := articles[id]; !ok {
+			delete(d.unseen, id)
+		}
+	}
+	d.trimNews()
+	d.renderNewsTable()
+	// the watchlist's news sentiment comes from the news table
+	d.renderStockTable()
+
+	firstLoad := !d.newsLoaded
+	d.newsLoaded = true
+	if firstLoad || added == 0 {
+		return nil
+	}
+
+	d.Session.Log.Infof("%d new headlines", added)
+	message := fmt.Sprintf("󰎕 %d new headline", added)
+	if added > 1 {
+		message += "s"
+	}
+	return func() tea.Msg {
+		return utils.SendNotificationMsg{Message: message, DisplayTime: 3000}
+	}
+}
+
+// Fill in what was scraped for an article the source doesn't give us the text of,
+// the fresh copy from the feed would otherwise lose it.
+func carryScraped(article *scraping.NewsArticle, scraped map[string]scraping.NewsArticle) {
+	if article.Readable {
+		return
+	}
+	old, ok := scraped[article.ID()]
+	if !ok {
+		return
+	}
+	article.Content = old.Content
+	article.Bullets = old.Bullets
+	article.ExtractedBy = old.ExtractedBy
+	article.Author = old.Author
+	article.AddTickers(old.Tickers)
+	article.Readable = true
+}
+
+// Make an article already in articleMap the story's representative. An earlier
+// article from another source would otherwise take over, changing the story's
+// ID so it shows up as unseen again and the cursor loses it.
+func (d *Dashboard) keepStoryID(story scraping.NewsArticle) scraping.NewsArticle {
+	if _, ok := d.articleMap[story.ID()]; ok {
+		return story
+	}
+	for i, duplicate := range story.Duplicates {
+		if _, ok := d.articleMap[duplicate.ID()]; !ok {
+			continue
+		}
+		others := slices.Concat([]scraping.NewsArticle{story}, story.Duplicates[:i], story.Duplicates[i+1:])
+		others[0].Duplicates = nil
+		duplicate.Duplicates = others
+		return duplicate
+	}
+	return story
+}
+
+// Articles in articleMap, newest first.
+func (d *Dashboard) sortedArticles() []scraping.NewsArticle {
+	articles := make([]scraping.NewsArticle, 0, len(d.articleMap))
+	for _, article := range d.articleMap {
+		articles = append(articles, article)
+	}
+	slices.SortStableFunc(articles, func(a, b scraping.NewsArticle) int {
+		if c := b.PublicationDate.Compare(a.PublicationDate); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.ID(), b.ID())
+	})
+	return articles
+}
+
+// Drop the oldest articles once there are more than news.max_articles.
+func (d *Dashboard) trimNews() {
+	limit := d.Session.Config.Int("news.max_articles")
+	if limit <= 0 || len(d.articleMap) <= limit {
+		return
+	}
+	for _, article := range d.sortedArticles()[limit:] {
+		delete(d.articleMap, article.ID())
+		delete(d.unseen, article.ID())
+	}
+}
+
+// Rebuild the news table rows, keeping the cursor on the same article.
+func (d *Dashboard) renderNewsTable() {
+	selected, hadSelection := d.selectedArticle()
+
+	var rows []table.Row
+	cursor := 0
+	now := time.Now()
+	for _, article := range d.sortedArticles() {
+		if !d.filter.Match(article, now) {
+			continue
+		}
+		id := article.ID()
+		if hadSelection && id == selected.ID() {
+			cursor = len(rows)
+		}
+
+		// Format the publication date
+		var formattedTime string
+
+		year, month, day := article.PublicationDate.Date()
+		nowYear, nowMonth, nowDay := time.Now().Date()
+		if year == nowYear && month == nowMonth && day == nowDay {
+			// If the article was published today, format it as HH:MM AM/PM
+			formattedTime = article.PublicationDate.Format("03:04 PM")
+		} else {
+			// Otherwise, format it as MM/DD
+			formattedTime = article.PublicationDate.Format("01/02")
+		}
+
+		// the title with a flag to show whether or not it's readable
+		title := article.Title
+		if article.Readable {
+			title = fmt.Sprintf("%s %s", "", title)
+		}
+		// how many other sources carry the story
+		if others := article.OtherSources(); others > 0 {
+			title = fmt.Sprintf("%s (+%d source", title, others)
+			if others > 1 {
+				title += "s"
+			}
+			title += ")"
+		}
+		// and whether it came in since the user last looked
+		if d.unseen[id] {
+			title = fmt.Sprintf("%s %s", "●", title)
+		}
+
+		rows = append(rows, table.Row{
+			title,
+			strings.Join(article.Tickers, " "),
+			article.Source,
+			formattedTime,
+			renderSentiment(article),
+			id,
+		})
+	}
+
+	d.tables[2].SetRows(rows)
+	d.tables[2].SetCursor(cursor)
+}
+
+// The article's sentiment and impact, e.g. a green "▲ 4" for a bullish article with an impact of 4.
+// This is the last visible column, the color would run into the columns after it.
+// The table counts escape codes in the column width, so the short color codes are used.
+func renderSentiment(article scraping.NewsArticle) string {
+	switch {
+	case article.Impact == 0:
+		return ""
+	case article.Sentiment == scraping.Bullish:
+		return fmt.Sprintf("\033[32m▲ %d", article.Impact) // green
+	case article.Sentiment == scraping.Bearish:
+		return fmt.Sprintf("\033[31m▼ %d", article.Impact) // red
+	}
+	return fmt.Sprintf("\033[90m• %d", article.Impact) // grey
+}
+
+// The article under the news table's cursor.
+func (d *Dashboard) selectedArticle() (scraping.NewsArticle, bool) {
+	row := d.tables[2].SelectedRow()
+	if len(row) <= newsIDColumn {
+		return scraping.NewsArticle{}, false
+	}
+	article, ok := d.articleMap[row[newsIDColumn]]
+	return article, ok
+}
+
+// Clear the unseen indicator of an article, or of every article if id is empty.
+func (d *Dashboard) markSeen(id string) {
+	if id == "" {
+		clear(d.unseen)
+	} else if !d.unseen[id] {
+		return
+	} else {
+		delete(d.unseen, id)
+	}
+	d.renderNewsTable()
+}
+
+// Update the article (or the source's version of it) that was just scraped in
+// the news modal, so the table shows it as readable and it opens instantly next time.
+func (d *Dashboard) markScraped(scraped scraping.NewsArticle) {
+	if !scraped.Readable {
+		return
+	}
+	key := scraping.NormalizeURL(scraped.URL)
+	fill := func(a *scraping.NewsArticle) bool {
+		if a.Readable || scraping.NormalizeURL(a.URL) != key {
+			return false
+		}
+		a.Content = scraped.Content
+		a.Bullets = scraped.Bullets
+		a.ExtractedBy = scraped.ExtractedBy
+		a.Author = scraped.Author
+		a.AddTickers(scraped.Tickers)
+		a.Readable = true
+		return true
+	}
+
+	for id, article := range d.articleMap {
+		changed := fill(&article)
+		article.Duplicates = slices.Clone(article.Duplicates)
+		for i := range article.Duplicates {
+			changed = fill(&article.Duplicates[i]) || changed
+		}
+		if changed {
+			d.articleMap[id] = article
+		}
+	}
+	d.renderNewsTable()
+}
+
+// Open the article in the news modal.
+func (d *Dashboard) openArticle(article scraping.NewsArticle) tea.Cmd {
+	d.markSeen(article.ID())
+	newsOverlay := components.NewsModal{
+		Session: d.Session,
+		Article: &article,
+		Chats:   d.chats,
+		W:       d.width / 2,
+		H:       int(float64(d.height) * .8),
+	}
+	return func() tea.Msg { return (&newsOverlay) }
+}
+
+// Show a brief of the news table, the watchlist and commodities.
+func (d *Dashboard) showBrief() tea.Cmd {
+	input := scraping.BriefInput{
+		News:        d.sortedArticles(),
+		Commodities: d.commodities,
+	}
+	for _, symbol := range d.WatchList.Symbols() {
+		if row, ok := d.lastRows[symbol]; ok {
+			input.Quotes = append(input.Quotes, scraping.BriefQuote{
+				Symbol:        row.Symbol,
+				Name:          row.CompanyName,
+				Price:         row.Price,
+				PercentChange: row.PercentChange,
+			})
+		}
+	}
+	brief := components.BriefModal{
+		Session:  d.Session,
+		Input:    input,
+		Articles: d.articleMap,
+		W:        d.width / 2,
+		H:        int(float64(d.height) * .8),
+	}
+	return func() tea.Msg { return DisplayOverlayMsg(&brief) }
+}
+
+// Open the overlay showing how each news source is doing.
+func (d *Dashboard) showSources() tea.Cmd {
+	list := components.SourceList{
+		Session: d.Session,
+		Width:   int(float64(d.width) * .8),
+		Height:  int(float64(d.height) * .8),
+	}
+	return func() tea.Msg { return DisplayOverlayMsg(&list) }
+}
@@ -0,0 +1,207 @@
+package views
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"gloomberg/internal/scraping"
+	"gloomberg/internal/utils"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+var newsFilterKeys = []key.Binding{
+	key.NewBinding(
+		key.WithKeys("/"),
+		key.WithHelp("/", "Filter"),
+	),
+	key.NewBinding(
+		key.WithKeys("V", "v"),
+		key.WithHelp("V/v", "Next view/Save view"),
+	),
+}
+
+// Sent by the filter prompt as the user types, Final is set when they press enter.
+type newsFilterMsg struct {
+	Query string
+	Final bool
+}
+
+// Sent by the prompt asking for the name to save the filter under.
+type saveNewsViewMsg string
+
+// Open the filter prompt, the news table is filtered as the user types.
+func (d *Dashboard) promptNewsFilter() tea.Cmd {
+	d.focusTable(2)
+	return func() tea.Msg {
+		return utils.PromptOpenMsg{
+			Prompt: "Filter news (source: since: ticker: readable:): ",
+			Value:  d.filter.Query,
+			ChangeFunc: func(s string) tea.Msg {
+				return newsFilterMsg{Query: s}
+			},
+			CallbackFunc: func(s string) tea.Msg {
+				return newsFilterMsg{Query: s, Final: true}
+			},
+		}
+	}
+}
+
+// Apply a filter from the prompt. While typing, half written filters like
+// "since:2" are shown as an error and the last valid filter stays applied.
+func (d *Dashboard) applyNewsFilter(msg newsFilterMsg) tea.Cmd {
+	filter, err := scraping.ParseNewsFilter(msg.Query)
+	if err != nil {
+		d.filterErr = err
+		if msg.Final {
+			return func() tea.Msg {
+				return utils.SendNotificationMsg{Message: err.Error(), DisplayTime: 3000}
+			}
+		}
+		return nil
+	}
+
+	d.filterErr = nil
+	d.filter = filter
+	// editing the filter leaves the view it came from
+	if view, ok := d.activeView(); !ok || view.Filter != filter.Query {
+		d.view = ""
+	}
+	d.renderNewsTable()
+	if msg.Final {
+		d.Session.Log.Infof("Filtering news by %q", filter.Query)
+	}
+	return nil
+}
+
+// The saved view that's applied, false if none is.
+func (d *Dashboard) activeView() (utils.NewsView, bool) {
+	views := d.Session.State.NewsViews(d.Session.Config)
+	i := slices.IndexFunc(views, func(v utils.NewsView) bool { return v.Name == d.view })
+	if d.view == "" || i < 0 {
+		return utils.NewsView{}, false
+	}
+	return views[i], true
+}
+
+// Apply the next saved view, after the last view the filter is cleared.
+func (d *Dashboard) cycleNewsView() tea.Cmd {
+	views := d.Session.State.NewsViews(d.Session.Config)
+	if len(views) == 0 {
+		return func() tea.Msg {
+			return utils.SendNotificationMsg{
+				Message:     "No saved views, press v to save the current filter",
+				DisplayTime: 3000,
+			}
+		}
+	}
+
+	next := slices.IndexFunc(views, func(v utils.NewsView) bool { return v.Name == d.view }) + 1
+	if next >= len(views) {
+		d.view = ""
+		d.filter = scraping.NewsFilter{}
+		d.filterErr = nil
+		d.renderNewsTable()
+		return nil
+	}
+
+	view := views[next]
+	filter, err := scraping.ParseNewsFilter(view.Filter)
+	if err != nil {
+		d.Session.Log.Errorf("Invalid filter in news view %s: %v", view.Name, err)
+		return func() tea.Msg {
+			return utils.SendNotificationMsg{
+				Message:     fmt.Sprintf("View %s has an invalid filter: %s", view.Name, err),
+				DisplayTime: 3000,
+			}
+		}
+	}
+	d.view = view.Name
+	d.filter = filter
+	d.filterErr = nil
+	d.renderNewsTable()
+	return nil
+}
+
+// Ask for a name and save the current filter as a view.
+func (d *Dashboard) promptSaveNewsView() tea.Cmd {
+	if d.filter.Empty() {
+		return func() tea.Msg {
+			return utils.SendNotificationMsg{Message: "Nothing to save, press / to filter the news first", DisplayTime: 3000}
+		}
+	}
+	return func() tea.Msg {
+		return utils.PromptOpenMsg{
+			Prompt: fmt.Sprintf("Save %q as view: ", d.filter.Query),
+			Value:  d.view,
+			CallbackFunc: func(s string) tea.Msg {
+				return saveNewsViewMsg(strings.TrimSpace(s))
+			},
+		}
+	}
+}
+
+func (d *Dashboard) saveNewsView(name string) tea.Cmd {
+	if name == "" {
+		return nil
+	}
+	d.Session.State.SaveNewsView(utils.NewsView{Name: name, Filter: d.filter.Query})
+	d.Session.SaveState()
+	d.view = name
+	return func() tea.Msg {
+		return utils.SendNotificationMsg{Message: fmt.Sprintf("Saved view %s", name), DisplayTime: 3000}
+	}
+}
+
+// Text for the news table's border, the active view or filter.
+func (d *Dashboard) newsTitle() string {
+	title := "News"
+	if view, ok := d.activeView(); ok {
+		title = fmt.Sprintf("News · %s: %s", view.Name, view.Filter)
+	} else if !d.filter.Empty() {
+		title = fmt.Sprintf("News · /%s", d.filter.Query)
+	}
+	if !d.filter.Empty() {
+		title += fmt.Sprintf(" (%d of %d)", len(d.tables[2].Rows()), len(d.articleMap))
+	}
+	if d.filterErr != nil {
+		title += " · " + d.filterErr.Error()
+	}
+	return title
+}
+
+// Render content inside a border with a title set into the top edge.
+func borderWithTitle(style lipgloss.Style, renderer *lipgloss.Renderer, content string, title string) string {
+	body := style.BorderTop(false).Render(content)
+	width := lipgloss.Width(body)
+
+	border := style.GetBorderStyle()
+	// leave room for the corners and the padding around the title
+	if maxTitle := width - 6; maxTitle > 0 {
+		// by display width, queries can have wide characters
+		title = ansi.Truncate(title, maxTitle, "…")
+	}
+	fill := width - lipgloss.Width(title) - 5
+	if fill < 0 {
+		fill = 0
+	}
+	top := border.TopLeft + border.Top + " " + title + " " + strings.Repeat(border.Top, fill) + border.TopRight
+
+	topStyle := renderer.NewStyle().Foreground(style.GetBorderTopForeground())
+	return lipgloss.JoinVertical(0, topStyle.Render(top), body)
+}
+
+// Filter the news to stories about the selected watchlist symbol.
+func (d *Dashboard) filterNewsBySymbol() tea.Cmd {
+	selected, ok := d.selectedStockRow()
+	if !ok || selected.Header {
+		return nil
+	}
+	d.focusTable(2)
+	return d.applyNewsFilter(newsFilterMsg{Query: "ticker:" + selected.Symbol, Final: true})
+}
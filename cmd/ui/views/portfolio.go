@@ -0,0 +1,322 @@
+package views
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"gloomberg/internal/hub"
+	"gloomberg/internal/utils"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/table"
+	"github.com/charmbracelet/lipgloss"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Tab showing the user's positions, valued with the same quotes as the watchlist.
+type Portfolio struct {
+	Session *utils.Session
+	// screen height
+	height int
+	// screen width
+	width int
+
+	table     table.Model
+	positions []utils.Position
+	// latest quote for every symbol in the portfolio
+	quotes map[string]utils.Quote
+	// error from loading the positions file, shown instead of the table
+	loadErr error
+
+	// the session's subscription to the market data hub, shared with the other tabs
+	Feed *hub.Subscription
+	// symbols the subscription watches
+	watched []string
+}
+
+// A position valued at the latest quote.
+type valuedPosition struct {
+	utils.Position
+	Price float64
+	// whether there's a quote for this position yet
+	Priced        bool
+	MarketValue   float64
+	DayPnL        float64
+	UnrealizedPnL float64
+	// percentage of the portfolio (in the same currency) this position makes up
+	Allocation float64
+}
+
+// Totals for every position held in one currency.
+type portfolioTotals struct {
+	Currency      string
+	MarketValue   float64
+	CostValue     float64
+	DayPnL        float64
+	UnrealizedPnL float64
+}
+
+// Format an amount of money, USD amounts get a dollar sign like the rest of the app.
+func formatMoney(amount float64, currency string) string {
+	if currency == "" || currency == "USD" {
+		return fmt.Sprintf("$%.2f", amount)
+	}
+	return fmt.Sprintf("%.2f %s", amount, currency)
+}
+
+// Format a profit or loss with an explicit sign.
+func formatPnL(amount float64, currency string) string {
+	if amount >= 0 {
+		return "+" + formatMoney(amount, currency)
+	}
+	return "-" + formatMoney(-amount, currency)
+}
+
+// Value every position at the latest quotes and total them up per currency.
+func (p *Portfolio) valuePositions() ([]valuedPosition, []portfolioTotals) {
+	var valued []valuedPosition
+	totals := make(map[string]*portfolioTotals)
+	var currencies []string
+
+	for _, pos := range p.positions {
+		v := valuedPosition{Position: pos}
+		q, ok := p.quotes[pos.Symbol]
+		if ok {
+			v.Priced = true
+			v.Price = q.Price
+			v.MarketValue = pos.Quantity * q.Price
+			v.DayPnL = pos.Quantity * q.Change
+			v.UnrealizedPnL = pos.Quantity * (q.Price - pos.CostBasis)
+			if v.Currency == "" {
+				v.Currency = q.Currency
+			}
+		}
+
+		t, ok := totals[v.Currency]
+		if !ok {
+			t = &portfolioTotals{Currency: v.Currency}
+			totals[v.Currency] = t
+			currencies = append(currencies, v.Currency)
+		}
+		if v.Priced {
+			t.MarketValue += v.MarketValue
+			t.CostValue += pos.Quantity * pos.CostBasis
+			t.DayPnL += v.DayPnL
+			t.UnrealizedPnL += v.UnrealizedPnL
+		}
+		valued = append(valued, v)
+	}
+
+	for i, v := range valued {
+		if t := totals[v.Currency]; t.MarketValue != 0 && v.Priced {
+			valued[i].Allocation = v.MarketValue / t.MarketValue * 100
+		}
+	}
+
+	var summary []portfolioTotals
+	for _, c := range currencies {
+		summary = append(summary, *totals[c])
+	}
+	return valued, summary
+}
+
+// Read the positions file and start watching every symbol in it.
+func (p *Portfolio) loadPositions() {
+	path, err := utils.PositionsPath(p.Session.Config)
+	if err == nil {
+		p.positions, err = utils.LoadPositions(path)
+	}
+	p.loadErr = err
+	if err != nil {
+		p.Session.Log.Errorf("Cannot load positions: %v", err)
+		return
+	}
+	p.Session.Log.Infof("Loaded %d positions from %s", len(p.positions), path)
+
+	var symbols []string
+	for _, pos := range p.positions {
+		if !slices.Contains(symbols, pos.Symbol) {
+			symbols = append(symbols, pos.Symbol)
+		}
+	}
+	// watching the new symbols first keeps positions that are still held from being dropped in between
+	p.Feed.Watch(symbols...)
+	p.Feed.Unwatch(p.watched...)
+	p.watched = symbols
+}
+
+func (p *Portfolio) Init() tea.Cmd {
+	p.quotes = make(map[string]utils.Quote)
+
+	accentColor := p.Session.Config.String("theme.accentColor")
+	p.table = table.New(table.WithFocused(true))
+	p.table.SetStyles(table.Styles{
+		Header: p.Session.Renderer.NewStyle().
+			Align(lipgloss.Center).
+			Bold(true).
+			Foreground(lipgloss.Color("#FFFFFF")),
+		Cell:     p.Session.Renderer.NewStyle(),
+		Selected: p.Session.Renderer.NewStyle().Bold(true).Foreground(lipgloss.Color(accentColor)),
+	})
+
+	p.resizeTable()
+	p.loadPositions()
+	p.renderTable()
+	return nil
+}
+
+// Size the table to the screen. Also called from Init, so the table has
+// columns before the positions are rendered into it.
+func (p *Portfolio) resizeTable() {
+	tableWidth := p.width - 2
+	p.table.SetWidth(tableWidth)
+	// leave room for the summary bar and borders
+	p.table.SetHeight(p.height - 8)
+
+	p.table.SetColumns([]table.Column{
+		{Title: "Symbol", Width: int(float64(tableWidth) * .16)},
+		{Title: "Qty", Width: int(float64(tableWidth) * .08)},
+		{Title: "Cost", Width: int(float64(tableWidth) * .11)},
+		{Title: "Price", Width: int(float64(tableWidth) * .11)},
+		{Title: "Value", Width: int(float64(tableWidth) * .13)},
+		{Title: "Day P&L", Width: int(float64(tableWidth) * .13)},
+		{Title: "Unrealized P&L", Width: int(float64(tableWidth) * .16)},
+		{Title: "Alloc", Width: int(float64(tableWidth) * .08)},
+	})
+}
+
+func (p *Portfolio) renderTable() {
+	valued, _ := p.valuePositions()
+
+	var rows []table.Row
+	for _, v := range valued {
+		if !v.Priced {
+			rows = append(rows, table.Row{
+				v.Symbol,
+				fmt.Sprintf("%g", v.Quantity),
+				formatMoney(v.CostBasis, v.Currency),
+				"…", "", "", "", "",
+			})
+			continue
+		}
+
+		var color string
+		if v.DayPnL >= 0 {
+			color = "\033[38;5;46m" // green
+		} else {
+			color = "\033[38;5;196m" // red
+		}
+		rows = append(rows, table.Row{
+			fmt.Sprintf("%s%s", color, v.Symbol),
+			fmt.Sprintf("%g", v.Quantity),
+			formatMoney(v.CostBasis, v.Currency),
+			formatMoney(v.Price, v.Currency),
+			formatMoney(v.MarketValue, v.Currency),
+			formatPnL(v.DayPnL, v.Currency),
+			formatPnL(v.UnrealizedPnL, v.Currency),
+			fmt.Sprintf("%.1f%%", v.Allocation),
+		})
+	}
+	p.table.SetRows(rows)
+}
+
+func (p *Portfolio) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	var cmd tea.Cmd
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		p.width = msg.Width
+		p.height = msg.Height - 1
+
+		p.resizeTable()
+
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "r":
+			p.Session.Log.Info("Reloading positions")
+			p.loadPositions()
+			p.renderTable()
+			return p, nil
+		}
+		p.table, cmd = p.table.Update(msg)
+
+	case hub.QuoteUpdateMsg:
+		for _, result := range msg {
+			// the subscription also carries the dashboard's watchlist
+			if result.Err != nil || !slices.Contains(p.watched, result.Symbol) {
+				continue
+			}
+			p.quotes[result.Symbol] = result.Quote
+		}
+		p.renderTable()
+	}
+
+	return p, cmd
+}
+
+// Render the totals for each currency.
+func (p *Portfolio) renderSummary() string {
+	_, totals := p.valuePositions()
+
+	labelStyle := p.Session.Renderer.NewStyle().
+		Bold(true).
+		Foreground(lipgloss.Color(p.Session.Config.String("theme.accentColor")))
+
+	var lines []string
+	for _, t := range totals {
+		var unrealizedPercent, dayPercent float64
+		if t.CostValue != 0 {
+			unrealizedPercent = t.UnrealizedPnL / t.CostValue * 100
+		}
+		if previous := t.MarketValue - t.DayPnL; previous != 0 {
+			dayPercent = t.DayPnL / previous * 100
+		}
+		currency := t.Currency
+		if currency == "" {
+			currency = "USD"
+		}
+		lines = append(lines, fmt.Sprintf("%s  %s %s  %s %s (%+.2f%%)  %s %s (%+.2f%%)",
+			labelStyle.Render(currency),
+			labelStyle.Render("Value"), formatMoney(t.MarketValue, t.Currency),
+			labelStyle.Render("Day P&L"), formatPnL(t.DayPnL, t.Currency), dayPercent,
+			labelStyle.Render("Unrealized P&L"), formatPnL(t.UnrealizedPnL, t.Currency), unrealizedPercent,
+		))
+	}
+	if len(lines) == 0 {
+		lines = append(lines, "No positions")
+	}
+
+	return p.Session.Renderer.NewStyle().
+		Border(lipgloss.NormalBorder()).
+		Width(p.width - 2).
+		Render(strings.Join(lines, "\n"))
+}
+
+func (p *Portfolio) View() string {
+	border := p.Session.Renderer.NewStyle().
+		Border(lipgloss.NormalBorder()).
+		BorderForeground(lipgloss.Color(p.Session.Config.String("theme.accentColor")))
+
+	if p.loadErr != nil {
+		return border.Width(p.width - 2).Render(fmt.Sprintf("Cannot load positions: %s", p.loadErr))
+	}
+	return lipgloss.JoinVertical(0, border.Render(p.table.View()), p.renderSummary())
+}
+
+func (p *Portfolio) GetKeys() []key.Binding {
+	return []key.Binding{
+		key.NewBinding(
+			key.WithKeys("k", "up"),
+			key.WithHelp("k/↑", "Move up"),
+		),
+		key.NewBinding(
+			key.WithKeys("j", "down"),
+			key.WithHelp("j/↓", "Move down"),
+		),
+		key.NewBinding(
+			key.WithKeys("r"),
+			key.WithHelp("r", "Reload positions"),
+		),
+	}
+}
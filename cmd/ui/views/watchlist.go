@@ -0,0 +1,240 @@
+package views
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"gloomberg/internal/scraping"
+	"gloomberg/internal/utils"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/table"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Keys for editing the watchlist while the stock table is focused.
+var watchListKeys = []key.Binding{
+	key.NewBinding(
+		key.WithKeys("x", "delete"),
+		key.WithHelp("x", "Remove Stock"),
+	),
+	key.NewBinding(
+		key.WithKeys("K", "shift+up", "J", "shift+down"),
+		key.WithHelp("K/J", "Move Stock"),
+	),
+	key.NewBinding(
+		key.WithKeys("e"),
+		key.WithHelp("e", "Set Group"),
+	),
+	key.NewBinding(
+		key.WithKeys("w"),
+		key.WithHelp("w", "Next Watchlist"),
+	),
+}
+
+// A row in the stock table, either a group header or a symbol.
+type stockRow struct {
+	Symbol string
+	// Name of the group, only set on header rows
+	Group  string
+	Header bool
+}
+
+// Build the header row for a group, showing how many symbols are in it
+// and their average percent change.
+func renderGroupHeader(name string, rows []RowData) table.Row {
+	var total float64
+	for _, row := range rows {
+		total += row.PercentChange
+	}
+
+	var average string
+	var color string
+	if len(rows) > 0 {
+		avg := total / float64(len(rows))
+		average = fmt.Sprintf("%.2f", avg)
+		if avg >= 0 {
+			color = "\033[38;5;46m" // green
+		} else {
+			color = "\033[38;5;196m" // red
+		}
+	}
+
+	return table.Row{
+		fmt.Sprintf("\033[1m%s── %s (%d)", color, name, len(rows)),
+		"",
+		"",
+		average,
+		"",
+	}
+}
+
+// Row for a symbol that has never loaded, either still loading or failing to.
+func renderPendingRow(symbol string, err error) table.Row {
+	status := "\033[38;5;244m" + symbol + " (loading)" // grey
+	if err != nil {
+		status = "\033[38;5;196m" + symbol + " (cannot load)" // red
+	}
+	return table.Row{status, "-", "-", "-"}
+}
+
+// A symbol's news sentiment, e.g. "▲+0.42 (6)" from 6 mostly bullish articles.
+// The table counts escape codes in the column width, so the short color codes are used.
+func renderTickerSentiment(sentiment scraping.TickerSentiment) string {
+	if sentiment.Articles == 0 {
+		return ""
+	}
+	switch {
+	case sentiment.Score >= .2:
+		return fmt.Sprintf("\033[32m▲%+.2f (%d)", sentiment.Score, sentiment.Articles) // green
+	case sentiment.Score <= -.2:
+		return fmt.Sprintf("\033[31m▼%+.2f (%d)", sentiment.Score, sentiment.Articles) // red
+	}
+	return fmt.Sprintf("\033[90m•%+.2f (%d)", sentiment.Score, sentiment.Articles) // grey
+}
+
+// Redraw the stock table from the watchlist, keeping the cursor on the same row.
+func (d *Dashboard) renderStockTable() {
+	selected, _ := d.selectedStockRow()
+
+	window := d.Session.Config.Duration("news.sentiment.window")
+	sentiments := scraping.SentimentByTicker(d.sortedArticles(), time.Now().Add(-window))
+
+	var tableRows []table.Row
+	var stockRows []stockRow
+	for _, group := range d.WatchList.Groups {
+		var rows []RowData
+		for _, symbol := range group.Symbols {
+			if row, ok := d.lastRows[symbol]; ok {
+				rows = append(rows, row)
+			}
+		}
+
+		// ungrouped symbols are shown without a header
+		if group.Name != "" {
+			tableRows = append(tableRows, renderGroupHeader(group.Name, rows))
+			stockRows = append(stockRows, stockRow{Group: group.Name, Header: true})
+		}
+		for _, symbol := range group.Symbols {
+			row, ok := d.lastRows[symbol]
+			rendered := row.Render()
+			if !ok {
+				// symbols without data still get a row so they can be removed or moved
+				rendered = renderPendingRow(symbol, d.quoteErrs[symbol])
+			}
+			tableRows = append(tableRows, append(rendered, renderTickerSentiment(sentiments[symbol])))
+			stockRows = append(stockRows, stockRow{Symbol: symbol})
+		}
+	}
+
+	d.stockRows = stockRows
+	d.tables[1].SetRows(tableRows)
+
+	if i := slices.Index(d.stockRows, selected); i >= 0 {
+		d.tables[1].SetCursor(i)
+	}
+}
+
+// The row under the cursor in the stock table.
+func (d *Dashboard) selectedStockRow() (stockRow, bool) {
+	cursor := d.tables[1].Cursor()
+	if cursor < 0 || cursor >= len(d.stockRows) {
+		return stockRow{}, false
+	}
+	return d.stockRows[cursor], true
+}
+
+// Handle the watchlist editing keys, returns false if msg isn't one of them.
+func (d *Dashboard) editWatchList(msg tea.KeyMsg) (bool, tea.Cmd) {
+	switch msg.String() {
+	case "w":
+		d.cycleWatchList()
+		return true, nil
+	case "x", "delete", "K", "shift+up", "J", "shift+down", "e":
+	default:
+		return false, nil
+	}
+
+	row, ok := d.selectedStockRow()
+	if !ok || row.Header {
+		// nothing to do on group headers
+		return true, nil
+	}
+
+	switch msg.String() {
+	case "x", "delete":
+		d.WatchList.Remove(row.Symbol)
+		d.Feed.Unwatch(row.Symbol)
+		delete(d.lastRows, row.Symbol)
+		delete(d.quoteErrs, row.Symbol)
+		d.saveWatchList()
+		d.renderStockTable()
+		return true, func() tea.Msg {
+			return utils.SendNotificationMsg{
+				Message:     fmt.Sprintf("Removed $%s from watchlist", row.Symbol),
+				DisplayTime: 3000,
+			}
+		}
+	case "K", "shift+up":
+		d.WatchList.Move(row.Symbol, -1)
+	case "J", "shift+down":
+		d.WatchList.Move(row.Symbol, 1)
+	case "e":
+		return true, func() tea.Msg {
+			return utils.PromptOpenMsg{
+				Prompt: fmt.Sprintf("Group for $%s (empty to ungroup): ", row.Symbol),
+				CallbackFunc: func(s string) tea.Msg {
+					return setGroupMsg{Symbol: row.Symbol, Group: strings.TrimSpace(s)}
+				},
+			}
+		}
+	}
+
+	d.saveWatchList()
+	d.renderStockTable()
+	return true, nil
+}
+
+// Move a symbol into a group.
+type setGroupMsg struct {
+	Symbol string
+	Group  string
+}
+
+// Title of the stock table's first column, the name of the active watchlist.
+func (d *Dashboard) watchListTitle() string {
+	if len(d.watchLists) < 2 {
+		return d.WatchList.Name
+	}
+	i := slices.Index(d.watchLists, d.WatchList)
+	return fmt.Sprintf("%s (%d/%d)", d.WatchList.Name, i+1, len(d.watchLists))
+}
+
+// Switch the stock table to the next watchlist.
+func (d *Dashboard) cycleWatchList() {
+	if len(d.watchLists) < 2 {
+		return
+	}
+	i := slices.Index(d.watchLists, d.WatchList)
+	next := d.watchLists[(i+1)%len(d.watchLists)]
+	d.Session.Log.Infof("Switching to watchlist %s", next.Name)
+
+	// only the active watchlist is polled, watching the next one first keeps
+	// symbols in both lists from being dropped in between
+	d.Feed.Watch(next.Symbols()...)
+	d.Feed.Unwatch(d.WatchList.Symbols()...)
+	d.WatchList = next
+
+	d.Session.State.ActiveWatchlist = next.Name
+	d.Session.SaveState()
+
+	columns := d.tables[1].Columns()
+	if len(columns) > 0 {
+		columns[0].Title = d.watchListTitle()
+		d.tables[1].SetColumns(columns)
+	}
+	d.tables[1].SetCursor(0)
+	d.renderStockTable()
+}
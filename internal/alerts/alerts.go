@@ -0,0 +1,188 @@
+// Price alerts on watchlist symbols and commodities.
+package alerts
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// What an alert looks at.
+type Metric string
+
+const (
+	// The current price
+	Price Metric = "price"
+	// The percent change over the day
+	OneDay Metric = "1d"
+	// The percent change over the week, only known for commodities
+	SevenDay Metric = "7d"
+)
+
+// How the metric is compared to the threshold.
+type Operator string
+
+const (
+	Above Operator = "above"
+	Below Operator = "below"
+	// The metric moved further than the threshold in either direction
+	Beyond Operator = "beyond"
+)
+
+type Alert struct {
+	ID string `json:"id"`
+	// Symbol or commodity name the alert is on
+	Target    string   `json:"target"`
+	Metric    Metric   `json:"metric"`
+	Operator  Operator `json:"operator"`
+	Threshold float64  `json:"threshold"`
+	// When the alert last fired, it won't fire again until the cooldown has passed
+	LastFired time.Time `json:"lastFired,omitzero"`
+}
+
+// Describe the alert, e.g. "NVDA 1D beyond ±5.00%"
+func (a Alert) String() string {
+	switch a.Metric {
+	case Price:
+		return fmt.Sprintf("%s %s %.2f", a.Target, a.Operator, a.Threshold)
+	default:
+		var sign string
+		if a.Operator == Beyond {
+			sign = "±"
+		}
+		return fmt.Sprintf("%s %s %s %s%.2f%%", a.Target, strings.ToUpper(string(a.Metric)), a.Operator, sign, a.Threshold)
+	}
+}
+
+// Parse an alert on target from text like "above 200", "move 5" (1D beyond ±5%)
+// or "7d below -3". The metric defaults to the price if it's left out.
+func Parse(target string, text string) (Alert, error) {
+	fields := strings.Fields(strings.ToLower(text))
+	alert := Alert{Target: target, Metric: Price}
+
+	if len(fields) > 0 {
+		switch fields[0] {
+		case "price":
+			fields = fields[1:]
+		case "1d", "7d":
+			alert.Metric = Metric(fields[0])
+			fields = fields[1:]
+		case "move":
+			// shorthand for a daily move in either direction
+			alert.Metric = OneDay
+			fields = append([]string{string(Beyond)}, fields[1:]...)
+		}
+	}
+
+	if len(fields) != 2 {
+		return Alert{}, errors.New(`expected something like "above 200", "move 5" or "7d below -3"`)
+	}
+
+	switch op := Operator(fields[0]); op {
+	case Above, Below, Beyond:
+		alert.Operator = op
+	default:
+		return Alert{}, fmt.Errorf("unknown comparison %q, use above, below or beyond", fields[0])
+	}
+	if alert.Metric == Price && alert.Operator == Beyond {
+		return Alert{}, errors.New("beyond only works with 1d or 7d moves")
+	}
+
+	value := strings.TrimSuffix(strings.TrimPrefix(fields[1], "±"), "%")
+	threshold, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return Alert{}, fmt.Errorf("%q is not a number", fields[1])
+	}
+	if alert.Operator == Beyond {
+		threshold = math.Abs(threshold)
+	}
+	alert.Threshold = threshold
+
+	alert.ID = newID()
+	return alert, nil
+}
+
+func newID() string {
+	b := make([]byte, 6)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// The latest values for a symbol or commodity.
+type Observation struct {
+	Target string
+	Price  float64
+	// percent changes
+	OneDay   float64
+	SevenDay float64
+	// whether SevenDay is known
+	HasSevenDay bool
+}
+
+// An alert that went off and the value that set it off.
+type Fired struct {
+	Alert Alert
+	Value float64
+}
+
+// Describe why the alert fired.
+func (f Fired) String() string {
+	if f.Alert.Metric == Price {
+		return fmt.Sprintf("%s (now %.2f)", f.Alert, f.Value)
+	}
+	return fmt.Sprintf("%s (now %.2f%%)", f.Alert, f.Value)
+}
+
+// The observed value of the alert's metric, false if it isn't known.
+func (a Alert) value(o Observation) (float64, bool) {
+	switch a.Metric {
+	case Price:
+		return o.Price, true
+	case OneDay:
+		return o.OneDay, true
+	case SevenDay:
+		return o.SevenDay, o.HasSevenDay
+	}
+	return 0, false
+}
+
+func (a Alert) triggered(value float64) bool {
+	switch a.Operator {
+	case Above:
+		return value > a.Threshold
+	case Below:
+		return value < a.Threshold
+	case Beyond:
+		return math.Abs(value) > a.Threshold
+	}
+	return false
+}
+
+// Check every alert against the observations. Alerts that fire have their
+// LastFired set to now, and don't fire again until cooldown has passed.
+func Evaluate(list []Alert, observations []Observation, now time.Time, cooldown time.Duration) []Fired {
+	var fired []Fired
+	for i := range list {
+		a := &list[i]
+		if !a.LastFired.IsZero() && now.Sub(a.LastFired) < cooldown {
+			continue
+		}
+		for _, o := range observations {
+			if !strings.EqualFold(o.Target, a.Target) {
+				continue
+			}
+			value, ok := a.value(o)
+			if ok && a.triggered(value) {
+				a.LastFired = now
+				fired = append(fired, Fired{Alert: *a, Value: value})
+				break
+			}
+		}
+	}
+	return fired
+}
@@ -0,0 +1,123 @@
+package alerts
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		text string
+		want Alert
+	}{
+		{"above 200", Alert{Target: "NVDA", Metric: Price, Operator: Above, Threshold: 200}},
+		{"price below 120.5", Alert{Target: "NVDA", Metric: Price, Operator: Below, Threshold: 120.5}},
+		{"7d below -3", Alert{Target: "NVDA", Metric: SevenDay, Operator: Below, Threshold: -3}},
+		{"1D above 2%", Alert{Target: "NVDA", Metric: OneDay, Operator: Above, Threshold: 2}},
+		// move is a daily move either way, the sign of the threshold doesn't matter
+		{"move 5", Alert{Target: "NVDA", Metric: OneDay, Operator: Beyond, Threshold: 5}},
+		{"move -5", Alert{Target: "NVDA", Metric: OneDay, Operator: Beyond, Threshold: 5}},
+		{"7d beyond ±4%", Alert{Target: "NVDA", Metric: SevenDay, Operator: Beyond, Threshold: 4}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			got, err := Parse("NVDA", tt.text)
+			if err != nil {
+				t.Fatalf("Parse: %v", err)
+			}
+			if got.ID == "" {
+				t.Error("Parse should give the alert an ID")
+			}
+			got.ID = ""
+			if got != tt.want {
+				t.Errorf("Parse(%q) = %+v, want %+v", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := map[string]string{
+		"empty":          "",
+		"no threshold":   "above",
+		"too many words": "above 200 now",
+		"unknown op":     "over 200",
+		"not a number":   "above lots",
+		"price beyond":   "beyond 5",
+	}
+	for name, text := range tests {
+		t.Run(name, func(t *testing.T) {
+			if alert, err := Parse("NVDA", text); err == nil {
+				t.Errorf("Parse(%q) = %+v, want an error", text, alert)
+			}
+		})
+	}
+}
+
+func TestEvaluate(t *testing.T) {
+	observations := []Observation{
+		{Target: "NVDA", Price: 210, OneDay: -6.2},
+		{Target: "Crude Oil", Price: 71.3, OneDay: 0.4, SevenDay: -3.5, HasSevenDay: true},
+	}
+	tests := []struct {
+		name  string
+		alert Alert
+		// the value that set the alert off, 0 if it shouldn't fire
+		want float64
+	}{
+		{"price above", Alert{Target: "NVDA", Metric: Price, Operator: Above, Threshold: 200}, 210},
+		{"price not above", Alert{Target: "NVDA", Metric: Price, Operator: Above, Threshold: 220}, 0},
+		{"move down", Alert{Target: "NVDA", Metric: OneDay, Operator: Beyond, Threshold: 5}, -6.2},
+		{"7d below", Alert{Target: "Crude Oil", Metric: SevenDay, Operator: Below, Threshold: -3}, -3.5},
+		// targets match ignoring case
+		{"case insensitive", Alert{Target: "crude oil", Metric: Price, Operator: Below, Threshold: 80}, 71.3},
+		// stocks don't have a weekly change
+		{"7d unknown", Alert{Target: "NVDA", Metric: SevenDay, Operator: Below, Threshold: 100}, 0},
+		{"no observation", Alert{Target: "AAPL", Metric: Price, Operator: Above, Threshold: 1}, 0},
+	}
+
+	now := time.Date(2026, 10, 16, 15, 0, 0, 0, time.UTC)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			list := []Alert{tt.alert}
+			fired := Evaluate(list, observations, now, time.Hour)
+			if tt.want == 0 {
+				if len(fired) != 0 {
+					t.Errorf("Evaluate fired %+v, want nothing", fired)
+				}
+				return
+			}
+			if len(fired) != 1 || fired[0].Value != tt.want {
+				t.Fatalf("Evaluate = %+v, want one alert fired at %v", fired, tt.want)
+			}
+			if !list[0].LastFired.Equal(now) {
+				t.Errorf("LastFired = %s, want %s", list[0].LastFired, now)
+			}
+		})
+	}
+}
+
+func TestEvaluateCooldown(t *testing.T) {
+	observations := []Observation{{Target: "NVDA", Price: 210}}
+	list := []Alert{{Target: "NVDA", Metric: Price, Operator: Above, Threshold: 200}}
+	start := time.Date(2026, 10, 16, 15, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		after time.Duration
+		fires bool
+	}{
+		{0, true},
+		{time.Minute, false},
+		{59 * time.Minute, false},
+		// the cooldown is counted from the last time the alert fired
+		{time.Hour, true},
+		{time.Hour + 30*time.Minute, false},
+		{2 * time.Hour, true},
+	}
+	for _, tt := range tests {
+		fired := Evaluate(list, observations, start.Add(tt.after), time.Hour)
+		if (len(fired) == 1) != tt.fires {
+			t.Errorf("after %s: fired %+v, want fired %v", tt.after, fired, tt.fires)
+		}
+	}
+}
@@ -0,0 +1,388 @@
+// Process-wide market data hub. Every source is polled once no matter how
+// many sessions are connected, and updates are fanned out to each subscribed
+// session.
+package hub
+
+import (
+	"context"
+	"runtime/debug"
+	"slices"
+	"sync"
+	"time"
+
+	"gloomberg/internal/markets"
+	"gloomberg/internal/scraping"
+	"gloomberg/internal/utils"
+	"gloomberg/internal/webhook"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/log"
+	"github.com/knadh/koanf/v2"
+)
+
+// Anything that can receive bubbletea messages, usually a *tea.Program.
+type Sender interface {
+	Send(msg tea.Msg)
+}
+
+// Sent to a subscriber with the latest quotes for the symbols it watches.
+type QuoteUpdateMsg []utils.QuoteResult
+
+// How a news source has been doing.
+type SourceHealth struct {
+	Name     string
+	Category string
+	// when the source was last fetched, successfully or not
+	LastAttempt time.Time
+	LastSuccess time.Time
+	// how many articles the last successful fetch returned
+	Items   int
+	Latency time.Duration
+	// error from the last fetch, empty if it succeeded
+	LastError string
+}
+
+// Sent to every subscriber after the news sources are fetched.
+type SourceHealthMsg []SourceHealth
+
+type Hub struct {
+	// process-wide config, decides what sources are polled and how
+	config *koanf.Koanf
+	// where headlines matching webhook keywords are sent, can be nil
+	Webhooks *webhook.Dispatcher
+
+	mu sync.Mutex
+	// every open subscription
+	subscribers map[*Subscription]struct{}
+	// how many subscriptions are watching each symbol
+	symbols map[string]int
+	// the last successful quote for every watched symbol
+	quotes map[string]utils.QuoteResult
+	// the last commodity and news data, sent to new subscribers straight away
+	commodities scraping.CommodityUpdateMsg
+	news        scraping.NewsUpdate
+
+	// tags news with the symbols it mentions, learns company names from quotes
+	tagger *scraping.Tagger
+	// scores the sentiment and impact of news
+	scorer scraping.SentimentScorer
+
+	// the configured news sources and their health, in the same order
+	sources []scraping.NewsSource
+	health  []SourceHealth
+
+	// starts the polling loops on the first subscription
+	start sync.Once
+}
+
+// The hub shared by every session in this process, set up in main.
+var Shared *Hub
+
+func New(config *koanf.Koanf) *Hub {
+	h := &Hub{
+		config:      config,
+		subscribers: make(map[*Subscription]struct{}),
+		symbols:     make(map[string]int),
+		quotes:      make(map[string]utils.QuoteResult),
+		sources:     scraping.SourcesFromConfig(config),
+		tagger:      scraping.NewTagger(config),
+		scorer:      scraping.ScorerFromConfig(config),
+	}
+	for _, source := range h.sources {
+		h.health = append(h.health, SourceHealth{Name: source.Name(), Category: source.Category()})
+	}
+	return h
+}
+
+// The health of every news source, in config order.
+func (h *Hub) SourceHealth() []SourceHealth {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return slices.Clone(h.health)
+}
+
+// A single session's interest in the hub's data, shared by the session's tabs.
+type Subscription struct {
+	hub    *Hub
+	sender Sender
+	// how many of the session's tabs watch each symbol
+	symbols map[string]int
+}
+
+// Subscribe a session to the hub. Call SendLatest once the session can receive messages.
+func (h *Hub) Subscribe(sender Sender) *Subscription {
+	sub := &Subscription{
+		hub:     h,
+		sender:  sender,
+		symbols: make(map[string]int),
+	}
+
+	h.mu.Lock()
+	h.subscribers[sub] = struct{}{}
+	h.mu.Unlock()
+
+	h.start.Do(func() {
+		go h.poll("quotes", h.fetchQuotes)
+		go h.poll("commodities", h.fetchCommodities)
+		go h.poll("news", h.fetchNews)
+	})
+
+	log.Infof("Hub subscription added, %d subscribers", h.subscriberCount())
+	return sub
+}
+
+// Send the most recent commodity and news data, so a new session doesn't wait for the next poll.
+func (s *Subscription) SendLatest() {
+	h := s.hub
+	h.mu.Lock()
+	commodities := h.commodities
+	news := h.news
+	h.mu.Unlock()
+
+	if commodities != nil {
+		go s.sender.Send(commodities)
+	}
+	if news != nil {
+		go s.sender.Send(news)
+	}
+}
+
+func (h *Hub) subscriberCount() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return len(h.subscribers)
+}
+
+// Start watching symbols, symbols that are already being polled are sent from
+// the cache, new ones are fetched immediately. Each Watch of a symbol needs its own Unwatch.
+func (s *Subscription) Watch(symbols ...string) {
+	h := s.hub
+	var cached QuoteUpdateMsg
+	var missing []string
+
+	h.mu.Lock()
+	for _, symbol := range symbols {
+		s.symbols[symbol]++
+		if s.symbols[symbol] == 1 {
+			h.symbols[symbol]++
+		}
+
+		if q, ok := h.quotes[symbol]; ok {
+			cached = append(cached, q)
+		} else {
+			missing = append(missing, symbol)
+		}
+	}
+	h.mu.Unlock()
+
+	if len(cached) > 0 {
+		go s.sender.Send(cached)
+	}
+	if len(missing) > 0 {
+		go func() {
+			results := h.updateQuotes(missing)
+			s.sender.Send(QuoteUpdateMsg(results))
+		}()
+	}
+}
+
+// Stop watching symbols, a symbol stops being polled once no subscription watches it.
+func (s *Subscription) Unwatch(symbols ...string) {
+	h := s.hub
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for _, symbol := range symbols {
+		if s.symbols[symbol] <= 0 {
+			continue
+		}
+		s.symbols[symbol]--
+		if s.symbols[symbol] == 0 {
+			delete(s.symbols, symbol)
+			h.release(symbol)
+		}
+	}
+}
+
+// Remove the subscription from the hub, releasing every symbol it watched.
+func (s *Subscription) Close() {
+	h := s.hub
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if _, ok := h.subscribers[s]; !ok {
+		return
+	}
+	for symbol := range s.symbols {
+		h.release(symbol)
+	}
+	s.symbols = make(map[string]int)
+	delete(h.subscribers, s)
+	log.Infof("Hub subscription closed, %d subscribers", len(h.subscribers))
+}
+
+// Decrement the reference count of a symbol, must be called with h.mu held.
+func (h *Hub) release(symbol string) {
+	h.symbols[symbol]--
+	if h.symbols[symbol] <= 0 {
+		log.Infof("No sessions watching %s, no longer polling", symbol)
+		delete(h.symbols, symbol)
+		delete(h.quotes, symbol)
+	}
+}
+
+// Fetch quotes for symbols and update the cache with the successful ones.
+func (h *Hub) updateQuotes(symbols []string) []utils.QuoteResult {
+	results := utils.FetchConfiguredQuotes(h.config, symbols)
+
+	learned := false
+	h.mu.Lock()
+	for _, result := range results {
+		if result.Err != nil {
+			log.Errorf("Error fetching data for %s: %v", result.Symbol, result.Err)
+			continue
+		}
+		if h.tagger.AddCompany(result.Symbol, result.Quote.ShortName) {
+			learned = true
+		}
+		// only cache symbols that are still being watched
+		if _, ok := h.symbols[result.Symbol]; ok {
+			h.quotes[result.Symbol] = result
+		}
+	}
+	news := h.news
+	h.mu.Unlock()
+
+	// tag the news we already have with the companies we just learned about
+	if learned && news != nil {
+		news = scraping.NewsUpdate(h.tagger.TagAll(news))
+		h.mu.Lock()
+		h.news = news
+		h.mu.Unlock()
+		h.broadcast(news)
+	}
+	return results
+}
+
+// How long to wait between fetches of a source, configured under refresh.<source>.
+// Sources poll at their closed_interval while every market they follow is closed.
+func (h *Hub) interval(source string, now time.Time) time.Duration {
+	key := "refresh." + source
+	interval := h.config.Duration(key + ".interval")
+	if closed := h.config.Duration(key + ".closed_interval"); closed > 0 &&
+		!markets.AnyTrading(h.config.Strings(key+".markets"), now) {
+		interval = closed
+	}
+	if interval <= 0 {
+		interval = 5 * time.Second
+	}
+	return interval
+}
+
+// Call fetch right away and then every refresh interval of the source.
+func (h *Hub) poll(source string, fetch func()) {
+	for {
+		h.fetch(source, fetch)
+		interval := h.interval(source, time.Now())
+		log.Debugf("Next %s refresh in %s", source, interval)
+		time.Sleep(interval)
+	}
+}
+
+// Call fetch, a panic is logged instead of taking down every session with it.
+func (h *Hub) fetch(source string, fetch func()) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Errorf("Updating %s panicked: %v\n%s", source, r, debug.Stack())
+		}
+	}()
+	fetch()
+}
+
+func (h *Hub) fetchQuotes() {
+	h.mu.Lock()
+	symbols := make([]string, 0, len(h.symbols))
+	for symbol := range h.symbols {
+		symbols = append(symbols, symbol)
+	}
+	h.mu.Unlock()
+
+	if len(symbols) == 0 {
+		return
+	}
+
+	results := h.updateQuotes(symbols)
+
+	// send each subscriber only the symbols it watches
+	h.mu.Lock()
+	for sub := range h.subscribers {
+		var msg QuoteUpdateMsg
+		for _, result := range results {
+			if _, ok := sub.symbols[result.Symbol]; ok {
+				msg = append(msg, result)
+			}
+		}
+		if len(msg) > 0 {
+			go sub.sender.Send(msg)
+		}
+	}
+	h.mu.Unlock()
+}
+
+func (h *Hub) fetchCommodities() {
+	if h.subscriberCount() == 0 {
+		return
+	}
+	commodities, err := scraping.GetCommodities()
+	if err != nil {
+		log.Errorf("Cannot update commodities: %v", err)
+		return
+	}
+
+	h.mu.Lock()
+	h.commodities = commodities
+	h.mu.Unlock()
+	h.broadcast(commodities)
+}
+
+func (h *Hub) fetchNews() {
+	if h.subscriberCount() == 0 {
+		return
+	}
+	results := scraping.FetchSources(context.Background(), h.sources, h.config.Duration("news.timeout"))
+	news := scraping.MergeResults(results, h.config.Float64("news.dedup_threshold"))
+	news = scraping.NewsUpdate(h.tagger.TagAll(news))
+	news = scraping.NewsUpdate(scraping.ScoreAll(context.Background(), h.scorer, news))
+	// articles someone already scraped open without asking Gemini again
+	scraping.Cache.FillAll(news)
+
+	h.mu.Lock()
+	for i, result := range results {
+		health := &h.health[i]
+		// feeds without a configured name are named after their title once fetched
+		health.Name = result.Source.Name()
+		health.LastAttempt = result.FetchedAt
+		health.Latency = result.Latency
+		if result.Err != nil {
+			health.LastError = result.Err.Error()
+			continue
+		}
+		health.LastError = ""
+		health.LastSuccess = result.FetchedAt
+		health.Items = len(result.Articles)
+	}
+	h.news = news
+	health := SourceHealthMsg(slices.Clone(h.health))
+	h.mu.Unlock()
+
+	h.broadcast(news)
+	h.broadcast(health)
+	h.Webhooks.Headlines(news)
+}
+
+// Send a message to every subscriber.
+func (h *Hub) broadcast(msg tea.Msg) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for sub := range h.subscribers {
+		go sub.sender.Send(msg)
+	}
+}
@@ -0,0 +1,182 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/google/generative-ai-go/genai"
+	"github.com/knadh/koanf/v2"
+	"google.golang.org/api/iterator"
+	"google.golang.org/api/option"
+)
+
+// Google's Gemini models, configured under llm.gemini.
+type Gemini struct {
+	Options
+	// environment variable holding the API key
+	KeyEnv string
+}
+
+func NewGemini(config *koanf.Koanf, options Options) (Provider, error) {
+	g := &Gemini{Options: options, KeyEnv: config.String("llm.gemini.api_key_env")}
+	if g.Model == "" {
+		g.Model = "gemini-2.0-flash"
+	}
+	if g.KeyEnv == "" {
+		g.KeyEnv = "GEMINI_KEY"
+	}
+	return g, nil
+}
+
+func (g *Gemini) Name() string { return g.Model }
+
+func (g *Gemini) Generate(ctx context.Context, req Request) (string, error) {
+	ctx, cancel := g.context(ctx)
+	defer cancel()
+
+	client, chat, parts, err := g.chat(ctx, req)
+	if err != nil {
+		return "", err
+	}
+	defer client.Close()
+
+	resp, err := chat.SendMessage(ctx, parts...)
+	if err != nil {
+		return "", g.error(err)
+	}
+	return responseText(resp)
+}
+
+func (g *Gemini) Stream(ctx context.Context, req Request, chunk func(text string)) (string, error) {
+	ctx, cancel := g.context(ctx)
+	defer cancel()
+
+	client, chat, parts, err := g.chat(ctx, req)
+	if err != nil {
+		return "", err
+	}
+	defer client.Close()
+
+	var text strings.Builder
+	// why the answer ended, if it ended without any text
+	var ended error
+	responses := chat.SendMessageStream(ctx, parts...)
+	for {
+		resp, err := responses.Next()
+		if errors.Is(err, iterator.Done) {
+			break
+		} else if err != nil {
+			return "", g.error(err)
+		}
+		// the last response often only says why the answer ended
+		piece, err := responseText(resp)
+		if err != nil {
+			ended = err
+			continue
+		} else if piece == "" {
+			continue
+		}
+		text.WriteString(piece)
+		chunk(piece)
+	}
+	if text.Len() == 0 {
+		if ended != nil {
+			return "", ended
+		}
+		return "", errors.New("Gemini returned no answer")
+	}
+	return text.String(), nil
+}
+
+// Start a chat holding the request's earlier messages, returns the parts of the last one to send.
+func (g *Gemini) chat(ctx context.Context, req Request) (*genai.Client, *genai.ChatSession, []genai.Part, error) {
+	key := os.Getenv(g.KeyEnv)
+	if key == "" {
+		return nil, nil, nil, fmt.Errorf("Gemini key is not set, did you set $%s", g.KeyEnv)
+	}
+	client, err := genai.NewClient(ctx, option.WithAPIKey(key))
+	if err != nil {
+		if strings.Contains(err.Error(), "API key not valid") {
+			return nil, nil, nil, fmt.Errorf("Gemini key is not valid, check $%s", g.KeyEnv)
+		}
+		return nil, nil, nil, fmt.Errorf("cannot create Gemini client: %w", err)
+	}
+
+	model := client.GenerativeModel(g.Model)
+	model.SetTemperature(float32(g.Temperature))
+	if req.JSON || req.Schema != nil {
+		model.ResponseMIMEType = "application/json"
+		model.ResponseSchema = req.Schema.gemini()
+	}
+
+	chat := model.StartChat()
+	var last []genai.Part
+	for i, msg := range req.Messages {
+		switch {
+		case msg.Role == System:
+			if model.SystemInstruction == nil {
+				model.SystemInstruction = genai.NewUserContent()
+			}
+			model.SystemInstruction.Parts = append(model.SystemInstruction.Parts, genai.Text(msg.Content))
+		case i == len(req.Messages)-1:
+			last = append(last, genai.Text(msg.Content))
+		default:
+			role := "user"
+			if msg.Role == Assistant {
+				role = "model"
+			}
+			chat.History = append(chat.History, &genai.Content{Role: role, Parts: []genai.Part{genai.Text(msg.Content)}})
+		}
+	}
+	if len(req.Document) > 0 {
+		last = append([]genai.Part{genai.Blob{MIMEType: req.DocumentType, Data: req.Document}}, last...)
+	}
+	return client, chat, last, nil
+}
+
+func (g *Gemini) error(err error) error {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return fmt.Errorf("Gemini took longer than %s to answer", g.Timeout)
+	}
+	return err
+}
+
+// The text of the first candidate, or why there isn't one.
+func responseText(resp *genai.GenerateContentResponse) (string, error) {
+	if resp == nil {
+		return "", errors.New("Gemini returned no answer")
+	}
+	if resp.PromptFeedback != nil {
+		switch resp.PromptFeedback.BlockReason {
+		case genai.BlockReasonSafety:
+			return "", errors.New("Gemini blocked the request for safety reasons")
+		case genai.BlockReasonOther:
+			return "", errors.New("Gemini blocked the request")
+		}
+	}
+	if len(resp.Candidates) == 0 {
+		return "", errors.New("Gemini returned no answer")
+	}
+	candidate := resp.Candidates[0]
+	if candidate.Content == nil || len(candidate.Content.Parts) == 0 {
+		switch candidate.FinishReason {
+		case genai.FinishReasonSafety:
+			return "", errors.New("Gemini stopped answering for safety reasons")
+		case genai.FinishReasonRecitation:
+			return "", errors.New("Gemini stopped answering because it was reciting the page")
+		case genai.FinishReasonMaxTokens:
+			return "", errors.New("Gemini ran out of tokens before answering")
+		}
+		return "", errors.New("Gemini returned no answer")
+	}
+	var text strings.Builder
+	for _, part := range candidate.Content.Parts {
+		if txt, ok := part.(genai.Text); ok {
+			text.WriteString(string(txt))
+		}
+	}
+	return text.String(), nil
+}
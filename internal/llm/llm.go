@@ -0,0 +1,94 @@
+// Language models gloom uses to read articles. Providers are chosen in the
+// config under "llm", so articles can be sent to Gemini or kept on the
+// machine with a local Ollama or llama.cpp server.
+package llm
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/knadh/koanf/v2"
+)
+
+// Who wrote a message.
+type Role string
+
+const (
+	System    Role = "system"
+	User      Role = "user"
+	Assistant Role = "assistant"
+)
+
+type Message struct {
+	Role    Role
+	Content string
+}
+
+type Request struct {
+	// the conversation so far, the last message is the one being answered
+	Messages []Message
+	// a document sent along with the last message, such as an article's HTML
+	Document []byte
+	// MIME type of Document, e.g. "text/html"
+	DocumentType string
+	// whether the response must be a JSON object
+	JSON bool
+	// the shape the JSON object must have, implies JSON. Providers that can
+	// constrain their output to it do, others only get asked for JSON.
+	Schema *Schema
+}
+
+// A language model that answers requests.
+type Provider interface {
+	// name shown to the user, e.g. "gemini-2.0-flash"
+	Name() string
+	// Generate a response to the request.
+	Generate(ctx context.Context, req Request) (string, error)
+	// Generate a response, calling chunk with each piece of it as it's written.
+	// Returns the whole response once it's done.
+	Stream(ctx context.Context, req Request, chunk func(text string)) (string, error)
+}
+
+// Settings every provider shares.
+type Options struct {
+	Model       string
+	Temperature float64
+	// how long a single request may take, 0 for no limit
+	Timeout time.Duration
+}
+
+// Creates a provider from its section of the config, llm.<name>.
+type ProviderFactory func(config *koanf.Koanf, options Options) (Provider, error)
+
+var providers = map[string]ProviderFactory{
+	"gemini": NewGemini,
+	"openai": NewOpenAI,
+}
+
+// Add a provider that can be chosen with llm.provider.
+func RegisterProvider(name string, factory ProviderFactory) {
+	providers[name] = factory
+}
+
+// Create the provider chosen with llm.provider.
+func FromConfig(config *koanf.Koanf) (Provider, error) {
+	name := config.String("llm.provider")
+	factory, ok := providers[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown llm provider %q", name)
+	}
+	return factory(config, Options{
+		Model:       config.String("llm." + name + ".model"),
+		Temperature: config.Float64("llm.temperature"),
+		Timeout:     config.Duration("llm.timeout"),
+	})
+}
+
+// Apply the request timeout to ctx.
+func (o Options) context(ctx context.Context) (context.Context, context.CancelFunc) {
+	if o.Timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, o.Timeout)
+}
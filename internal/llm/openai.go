@@ -0,0 +1,220 @@
+package llm
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/knadh/koanf/v2"
+)
+
+// Any server with an OpenAI style chat completions API, configured under
+// llm.openai. Ollama serves one at http://localhost:11434/v1 and llama.cpp's
+// server at http://localhost:8080/v1.
+type OpenAI struct {
+	Options
+	// base URL of the API, /chat/completions is added to it
+	Endpoint string
+	// environment variable holding the API key, local servers don't need one
+	KeyEnv string
+}
+
+func NewOpenAI(config *koanf.Koanf, options Options) (Provider, error) {
+	o := &OpenAI{
+		Options:  options,
+		Endpoint: strings.TrimSuffix(config.String("llm.openai.endpoint"), "/"),
+		KeyEnv:   config.String("llm.openai.api_key_env"),
+	}
+	if o.Endpoint == "" {
+		return nil, errors.New("llm.openai.endpoint is not set")
+	}
+	if o.Model == "" {
+		return nil, errors.New("llm.openai.model is not set")
+	}
+	return o, nil
+}
+
+func (o *OpenAI) Name() string { return o.Model }
+
+type chatMessage struct {
+	Role    Role   `json:"role"`
+	Content string `json:"content"`
+}
+
+type chatRequest struct {
+	Model          string          `json:"model"`
+	Messages       []chatMessage   `json:"messages"`
+	Temperature    float64         `json:"temperature"`
+	Stream         bool            `json:"stream"`
+	ResponseFormat *responseFormat `json:"response_format,omitempty"`
+}
+
+type responseFormat struct {
+	Type       string      `json:"type"`
+	JSONSchema *jsonSchema `json:"json_schema,omitempty"`
+}
+
+type jsonSchema struct {
+	Name   string         `json:"name"`
+	Schema map[string]any `json:"schema"`
+}
+
+type chatResponse struct {
+	Choices []struct {
+		Message chatMessage `json:"message"`
+	} `json:"choices"`
+	Error *struct {
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// The request's messages, with the document added to the last one since
+// these APIs only take text.
+func (o *OpenAI) messages(req Request) []chatMessage {
+	messages := make([]chatMessage, 0, len(req.Messages))
+	for i, msg := range req.Messages {
+		content := msg.Content
+		if i == len(req.Messages)-1 && len(req.Document) > 0 {
+			content = fmt.Sprintf("%s\n\n%s:\n%s", content, req.DocumentType, req.Document)
+		}
+		messages = append(messages, chatMessage{Role: msg.Role, Content: content})
+	}
+	return messages
+}
+
+// Send the request, the caller closes the response's body.
+func (o *OpenAI) send(ctx context.Context, req Request, stream bool) (*http.Response, error) {
+	body := chatRequest{
+		Model:       o.Model,
+		Messages:    o.messages(req),
+		Temperature: o.Temperature,
+		Stream:      stream,
+	}
+	if req.Schema != nil {
+		body.ResponseFormat = &responseFormat{Type: "json_schema", JSONSchema: &jsonSchema{Name: "response", Schema: req.Schema.jsonSchema()}}
+	} else if req.JSON {
+		body.ResponseFormat = &responseFormat{Type: "json_object"}
+	}
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, o.Endpoint+"/chat/completions", bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	if key := os.Getenv(o.KeyEnv); o.KeyEnv != "" && key != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+key)
+	}
+
+	resp, err := http.DefaultClient.Do(httpReq)
+	if err != nil {
+		return nil, o.error(fmt.Errorf("cannot reach %s: %w", o.Endpoint, err))
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		content, _ := io.ReadAll(resp.Body)
+		var parsed chatResponse
+		if json.Unmarshal(content, &parsed) == nil && parsed.Error != nil {
+			return nil, fmt.Errorf("%s: %s", o.Model, parsed.Error.Message)
+		}
+		return nil, fmt.Errorf("%s returned %s: %s", o.Endpoint, resp.Status, strings.TrimSpace(string(content)))
+	}
+	return resp, nil
+}
+
+func (o *OpenAI) error(err error) error {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return fmt.Errorf("%s took longer than %s to answer", o.Model, o.Timeout)
+	}
+	return err
+}
+
+func (o *OpenAI) Generate(ctx context.Context, req Request) (string, error) {
+	ctx, cancel := o.context(ctx)
+	defer cancel()
+
+	resp, err := o.send(ctx, req, false)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	var parsed chatResponse
+	if err := json.NewDecoder(resp.Body).Decode(&parsed); err != nil {
+		return "", o.error(fmt.Errorf("cannot read answer from %s: %w", o.Endpoint, err))
+	}
+	if parsed.Error != nil {
+		return "", fmt.Errorf("%s: %s", o.Model, parsed.Error.Message)
+	}
+	if len(parsed.Choices) == 0 {
+		return "", fmt.Errorf("%s returned no answer", o.Model)
+	}
+	return parsed.Choices[0].Message.Content, nil
+}
+
+// A piece of a streamed answer.
+type chatChunk struct {
+	Choices []struct {
+		Delta chatMessage `json:"delta"`
+	} `json:"choices"`
+	Error *struct {
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// Streamed answers are sent as server-sent events, one "data: {chunk}" line
+// per piece and "data: [DONE]" at the end.
+func (o *OpenAI) Stream(ctx context.Context, req Request, chunk func(text string)) (string, error) {
+	ctx, cancel := o.context(ctx)
+	defer cancel()
+
+	resp, err := o.send(ctx, req, true)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	var text strings.Builder
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 64*1024), 4*1024*1024)
+	for scanner.Scan() {
+		data, ok := strings.CutPrefix(scanner.Text(), "data:")
+		if !ok {
+			continue
+		}
+		data = strings.TrimSpace(data)
+		if data == "[DONE]" {
+			break
+		}
+
+		var parsed chatChunk
+		if err := json.Unmarshal([]byte(data), &parsed); err != nil {
+			return "", fmt.Errorf("cannot read answer from %s: %w", o.Endpoint, err)
+		}
+		if parsed.Error != nil {
+			return "", fmt.Errorf("%s: %s", o.Model, parsed.Error.Message)
+		}
+		if len(parsed.Choices) == 0 || parsed.Choices[0].Delta.Content == "" {
+			continue
+		}
+		text.WriteString(parsed.Choices[0].Delta.Content)
+		chunk(parsed.Choices[0].Delta.Content)
+	}
+	if err := scanner.Err(); err != nil {
+		return "", o.error(err)
+	}
+	if text.Len() == 0 {
+		return "", fmt.Errorf("%s returned no answer", o.Model)
+	}
+	return text.String(), nil
+}
@@ -0,0 +1,90 @@
+package llm
+
+import "github.com/google/generative-ai-go/genai"
+
+// The type of a value in a Schema.
+type SchemaType string
+
+const (
+	String  SchemaType = "string"
+	Integer SchemaType = "integer"
+	Number  SchemaType = "number"
+	Boolean SchemaType = "boolean"
+	Array   SchemaType = "array"
+	Object  SchemaType = "object"
+)
+
+// The shape a JSON response must have. Only the parts of JSON Schema that
+// every provider understands are supported.
+type Schema struct {
+	Type        SchemaType
+	Description string
+	// fields of an object
+	Properties map[string]*Schema
+	// fields of an object that must be present
+	Required []string
+	// the schema of an array's items
+	Items *Schema
+	// the values a string may have, any if empty
+	Enum []string
+}
+
+// The schema as JSON Schema, for OpenAI style APIs.
+func (s *Schema) jsonSchema() map[string]any {
+	if s == nil {
+		return nil
+	}
+	schema := map[string]any{"type": string(s.Type)}
+	if s.Description != "" {
+		schema["description"] = s.Description
+	}
+	if s.Items != nil {
+		schema["items"] = s.Items.jsonSchema()
+	}
+	if len(s.Enum) > 0 {
+		schema["enum"] = s.Enum
+	}
+	if s.Type == Object {
+		properties := make(map[string]any, len(s.Properties))
+		for name, property := range s.Properties {
+			properties[name] = property.jsonSchema()
+		}
+		schema["properties"] = properties
+		schema["required"] = s.Required
+		schema["additionalProperties"] = false
+	}
+	return schema
+}
+
+var geminiTypes = map[SchemaType]genai.Type{
+	String:  genai.TypeString,
+	Integer: genai.TypeInteger,
+	Number:  genai.TypeNumber,
+	Boolean: genai.TypeBoolean,
+	Array:   genai.TypeArray,
+	Object:  genai.TypeObject,
+}
+
+// The schema as Gemini's response schema.
+func (s *Schema) gemini() *genai.Schema {
+	if s == nil {
+		return nil
+	}
+	schema := &genai.Schema{
+		Type:        geminiTypes[s.Type],
+		Description: s.Description,
+		Items:       s.Items.gemini(),
+		Required:    s.Required,
+	}
+	if len(s.Enum) > 0 {
+		schema.Format = "enum"
+		schema.Enum = s.Enum
+	}
+	if len(s.Properties) > 0 {
+		schema.Properties = make(map[string]*genai.Schema, len(s.Properties))
+		for name, property := range s.Properties {
+			schema.Properties[name] = property.gemini()
+		}
+	}
+	return schema
+}
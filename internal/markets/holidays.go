@@ -0,0 +1,33 @@
+package markets
+
+// Full day closures, extend with markets.holidays in the config for later years.
+
+var nyseHolidays = []string{
+	// 2025
+	"2025-01-01", "2025-01-09", "2025-01-20", "2025-02-17", "2025-04-18", "2025-05-26",
+	"2025-06-19", "2025-07-04", "2025-09-01", "2025-11-27", "2025-12-25",
+	// 2026
+	"2026-01-01", "2026-01-19", "2026-02-16", "2026-04-03", "2026-05-25", "2026-06-19",
+	"2026-07-03", "2026-09-07", "2026-11-26", "2026-12-25",
+}
+
+var xetraHolidays = []string{
+	// 2025
+	"2025-01-01", "2025-04-18", "2025-04-21", "2025-05-01", "2025-12-24", "2025-12-25",
+	"2025-12-26", "2025-12-31",
+	// 2026
+	"2026-01-01", "2026-04-03", "2026-04-06", "2026-05-01", "2026-12-24", "2026-12-25",
+	"2026-12-31",
+}
+
+var tseHolidays = []string{
+	// 2025
+	"2025-01-01", "2025-01-02", "2025-01-03", "2025-01-13", "2025-02-11", "2025-02-24",
+	"2025-03-20", "2025-04-29", "2025-05-05", "2025-05-06", "2025-07-21", "2025-08-11",
+	"2025-09-15", "2025-09-23", "2025-10-13", "2025-11-03", "2025-11-24", "2025-12-31",
+	// 2026
+	"2026-01-01", "2026-01-02", "2026-01-12", "2026-02-11", "2026-02-23", "2026-03-20",
+	"2026-04-29", "2026-05-04", "2026-05-05", "2026-05-06", "2026-07-20", "2026-08-11",
+	"2026-09-21", "2026-09-22", "2026-09-23", "2026-10-12", "2026-11-03", "2026-11-23",
+	"2026-12-31",
+}
@@ -0,0 +1,166 @@
+// Exchange calendar, tells whether the US, European and Asian markets are open.
+package markets
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/charmbracelet/log"
+	"github.com/knadh/koanf/v2"
+)
+
+type Status int
+
+const (
+	Closed Status = iota
+	PreMarket
+	Open
+	AfterHours
+)
+
+func (s Status) String() string {
+	switch s {
+	case PreMarket:
+		return "Pre-market"
+	case Open:
+		return "Open"
+	case AfterHours:
+		return "After-hours"
+	default:
+		return "Closed"
+	}
+}
+
+// Trading hours of an exchange, times are minutes after midnight in the exchange's time zone.
+type Exchange struct {
+	// Region the exchange stands for, e.g. "US"
+	Region   string
+	Name     string
+	Location *time.Location
+	// Start of pre-market trading, same as Open if there is none
+	PreOpen int
+	Open    int
+	Close   int
+	// End of after-hours trading, same as Close if there is none
+	PostClose int
+	// Midday break where the exchange is closed, both zero if there is none
+	BreakStart int
+	BreakEnd   int
+	// Dates (YYYY-MM-DD) the exchange is closed
+	Holidays map[string]bool
+}
+
+func hm(hour, minute int) int { return hour*60 + minute }
+
+func mustLoad(name string) *time.Location {
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		log.Errorf("Cannot load time zone %s, using UTC: %v", name, err)
+		return time.UTC
+	}
+	return loc
+}
+
+func dateSet(dates []string) map[string]bool {
+	set := make(map[string]bool, len(dates))
+	for _, d := range dates {
+		set[d] = true
+	}
+	return set
+}
+
+// The exchanges shown in the header, one per region.
+var Exchanges = []*Exchange{
+	{
+		Region:    "US",
+		Name:      "NYSE",
+		Location:  mustLoad("America/New_York"),
+		PreOpen:   hm(4, 0),
+		Open:      hm(9, 30),
+		Close:     hm(16, 0),
+		PostClose: hm(20, 0),
+		Holidays:  dateSet(nyseHolidays),
+	},
+	{
+		Region:    "EU",
+		Name:      "Xetra",
+		Location:  mustLoad("Europe/Berlin"),
+		PreOpen:   hm(8, 0),
+		Open:      hm(9, 0),
+		Close:     hm(17, 30),
+		PostClose: hm(22, 0),
+		Holidays:  dateSet(xetraHolidays),
+	},
+	{
+		Region:     "Asia",
+		Name:       "TSE",
+		Location:   mustLoad("Asia/Tokyo"),
+		PreOpen:    hm(9, 0),
+		Open:       hm(9, 0),
+		Close:      hm(15, 30),
+		PostClose:  hm(15, 30),
+		BreakStart: hm(11, 30),
+		BreakEnd:   hm(12, 30),
+		Holidays:   dateSet(tseHolidays),
+	},
+}
+
+// Find the exchange for a region.
+func ForRegion(region string) (*Exchange, bool) {
+	for _, e := range Exchanges {
+		if e.Region == region {
+			return e, true
+		}
+	}
+	return nil, false
+}
+
+// Add holidays from the config key markets.holidays, a map of region to dates,
+// for years the built in calendar doesn't cover yet.
+func LoadHolidays(config *koanf.Koanf) {
+	for _, region := range config.MapKeys("markets.holidays") {
+		e, ok := ForRegion(region)
+		if !ok {
+			log.Warnf("Unknown market region %s in markets.holidays", region)
+			continue
+		}
+		for _, date := range config.Strings(fmt.Sprintf("markets.holidays.%s", region)) {
+			e.Holidays[date] = true
+		}
+	}
+}
+
+// Whether the exchange is open, in pre-market or after-hours trading, or closed at t.
+func (e *Exchange) StatusAt(t time.Time) Status {
+	local := t.In(e.Location)
+	if local.Weekday() == time.Saturday || local.Weekday() == time.Sunday {
+		return Closed
+	}
+	if e.Holidays[local.Format("2006-01-02")] {
+		return Closed
+	}
+
+	minute := hm(local.Hour(), local.Minute())
+	switch {
+	case e.BreakStart != e.BreakEnd && minute >= e.BreakStart && minute < e.BreakEnd:
+		return Closed
+	case minute >= e.Open && minute < e.Close:
+		return Open
+	case minute >= e.PreOpen && minute < e.Open:
+		return PreMarket
+	case minute >= e.Close && minute < e.PostClose:
+		return AfterHours
+	default:
+		return Closed
+	}
+}
+
+// Whether any of the regions is trading at t, pre-market and after-hours count as trading.
+func AnyTrading(regions []string, t time.Time) bool {
+	for _, region := range regions {
+		if e, ok := ForRegion(region); ok && e.StatusAt(t) != Closed {
+			return true
+		}
+	}
+	return false
+}
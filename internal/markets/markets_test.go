@@ -0,0 +1,54 @@
+package markets
+
+import (
+	"testing"
+	"time"
+)
+
+func TestStatusAt(t *testing.T) {
+	nyse, _ := ForRegion("US")
+	tse, _ := ForRegion("Asia")
+	newYork := nyse.Location
+	tokyo := tse.Location
+
+	tests := []struct {
+		name     string
+		exchange *Exchange
+		at       time.Time
+		want     Status
+	}{
+		// Friday 2026-10-16 in New York
+		{"before pre-market", nyse, time.Date(2026, 10, 16, 3, 59, 0, 0, newYork), Closed},
+		{"pre-market opens", nyse, time.Date(2026, 10, 16, 4, 0, 0, 0, newYork), PreMarket},
+		{"last pre-market minute", nyse, time.Date(2026, 10, 16, 9, 29, 0, 0, newYork), PreMarket},
+		{"open", nyse, time.Date(2026, 10, 16, 9, 30, 0, 0, newYork), Open},
+		{"last open minute", nyse, time.Date(2026, 10, 16, 15, 59, 0, 0, newYork), Open},
+		{"after-hours", nyse, time.Date(2026, 10, 16, 16, 0, 0, 0, newYork), AfterHours},
+		{"last after-hours minute", nyse, time.Date(2026, 10, 16, 19, 59, 0, 0, newYork), AfterHours},
+		{"after-hours ends", nyse, time.Date(2026, 10, 16, 20, 0, 0, 0, newYork), Closed},
+		// the status is for the exchange's time zone, not the time's
+		{"friday evening in new york, saturday in utc", nyse, time.Date(2026, 10, 16, 23, 59, 0, 0, time.UTC), AfterHours},
+		{"sunday in new york, monday in utc", nyse, time.Date(2026, 10, 19, 3, 30, 0, 0, time.UTC), Closed},
+		{"saturday", nyse, time.Date(2026, 10, 17, 10, 0, 0, 0, newYork), Closed},
+		{"sunday", nyse, time.Date(2026, 10, 18, 10, 0, 0, 0, newYork), Closed},
+		{"monday", nyse, time.Date(2026, 10, 19, 10, 0, 0, 0, newYork), Open},
+		// Thanksgiving
+		{"holiday", nyse, time.Date(2026, 11, 26, 10, 0, 0, 0, newYork), Closed},
+		{"holiday pre-market", nyse, time.Date(2026, 11, 26, 5, 0, 0, 0, newYork), Closed},
+		{"day after holiday", nyse, time.Date(2026, 11, 27, 10, 0, 0, 0, newYork), Open},
+
+		// Tokyo has no extended hours but closes for lunch
+		{"tokyo opens", tse, time.Date(2026, 10, 16, 9, 0, 0, 0, tokyo), Open},
+		{"tokyo lunch", tse, time.Date(2026, 10, 16, 11, 30, 0, 0, tokyo), Closed},
+		{"tokyo after lunch", tse, time.Date(2026, 10, 16, 12, 30, 0, 0, tokyo), Open},
+		{"tokyo closes", tse, time.Date(2026, 10, 16, 15, 30, 0, 0, tokyo), Closed},
+		{"tokyo holiday", tse, time.Date(2026, 10, 12, 10, 0, 0, 0, tokyo), Closed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.exchange.StatusAt(tt.at); got != tt.want {
+				t.Errorf("%s StatusAt(%s) = %s, want %s", tt.exchange.Name, tt.at, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,243 @@
+package scraping
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"gloomberg/internal/llm"
+
+	"github.com/charmbracelet/log"
+	"github.com/knadh/koanf/v2"
+)
+
+// A watchlist symbol's latest quote.
+type BriefQuote struct {
+	Symbol        string
+	Name          string
+	Price         float64
+	PercentChange float64
+}
+
+// What the brief is written from.
+type BriefInput struct {
+	// newest first
+	News        NewsUpdate
+	Quotes      []BriefQuote
+	Commodities []Commodity
+}
+
+// A part of the brief, e.g. a sector or a watchlist symbol.
+type BriefSection struct {
+	Title   string
+	Summary string
+	// IDs of the articles the section is based on
+	Articles []string
+}
+
+// A digest of the news and the market, written by the model configured under "llm".
+type Brief struct {
+	Macro     BriefSection
+	Sectors   []BriefSection
+	Watchlist []BriefSection
+	// how many headlines it was written from
+	Headlines   int
+	GeneratedAt time.Time
+	// name of the model that wrote it
+	GeneratedBy string
+}
+
+const briefPrompt = `You are a financial news editor writing a morning market brief for a trader.
+Below are the latest headlines, numbered, followed by the trader's watchlist quotes and commodity moves.
+Write a short, factual digest in JSON like this:
+{
+	"macro": { "summary": <markdown>, "articles": [<headline numbers>] }, // rates, inflation, central banks, geopolitics and the overall market
+	"sectors": [{ "name": <sector>, "summary": <markdown>, "articles": [<headline numbers>] }], // the 3 to 6 sectors with the most news
+	"watchlist": [{ "symbol": <symbol>, "summary": <markdown>, "articles": [<headline numbers>] }] // one entry per watchlist symbol
+}
+Summaries are 1 to 3 sentences. Only state what the headlines and numbers support, and say so when
+a watchlist symbol has no news instead of guessing why it moved. "articles" lists the numbers of the
+headlines a summary is based on, do not cite them in the summary itself.
+`
+
+// The shape of the answer to briefPrompt.
+var briefSchema = func() *llm.Schema {
+	articles := &llm.Schema{Type: llm.Array, Items: &llm.Schema{Type: llm.Integer}, Description: "numbers of the headlines the summary is based on"}
+	summary := &llm.Schema{Type: llm.String, Description: "1 to 3 sentences of markdown"}
+	section := func(name string, description string) *llm.Schema {
+		return &llm.Schema{
+			Type: llm.Object,
+			Properties: map[string]*llm.Schema{
+				name:       {Type: llm.String, Description: description},
+				"summary":  summary,
+				"articles": articles,
+			},
+			Required: []string{name, "summary", "articles"},
+		}
+	}
+	return &llm.Schema{
+		Type: llm.Object,
+		Properties: map[string]*llm.Schema{
+			"macro": {
+				Type:       llm.Object,
+				Properties: map[string]*llm.Schema{"summary": summary, "articles": articles},
+				Required:   []string{"summary", "articles"},
+			},
+			"sectors":   {Type: llm.Array, Items: section("name", "the sector"), Description: "the 3 to 6 sectors with the most news"},
+			"watchlist": {Type: llm.Array, Items: section("symbol", "the watchlist symbol"), Description: "one entry per watchlist symbol"},
+		},
+		Required: []string{"macro", "sectors", "watchlist"},
+	}
+}()
+
+// How the model answers, with articles as headline numbers.
+type briefResponse struct {
+	Macro struct {
+		Summary  string `json:"summary"`
+		Articles []int  `json:"articles"`
+	} `json:"macro"`
+	Sectors []struct {
+		Name     string `json:"name"`
+		Summary  string `json:"summary"`
+		Articles []int  `json:"articles"`
+	} `json:"sectors"`
+	Watchlist []struct {
+		Symbol   string `json:"symbol"`
+		Summary  string `json:"summary"`
+		Articles []int  `json:"articles"`
+	} `json:"watchlist"`
+}
+
+// Briefs written this hour, shared by every session so the model is only asked
+// once an hour for the same watchlist. Keyed by briefKey.
+var briefs = struct {
+	sync.Mutex
+	m map[string]Brief
+}{m: make(map[string]Brief)}
+
+// Briefs are cached per clock hour and watchlist.
+func briefKey(input BriefInput, now time.Time) string {
+	symbols := make([]string, 0, len(input.Quotes))
+	for _, quote := range input.Quotes {
+		symbols = append(symbols, quote.Symbol)
+	}
+	slices.Sort(symbols)
+	return now.Truncate(time.Hour).Format(time.RFC3339) + " " + strings.Join(symbols, ",")
+}
+
+// The brief for this hour, written with news.brief.max_headlines of the newest headlines.
+// A brief already written this hour is reused unless regenerate is set.
+func GenerateBrief(ctx context.Context, config *koanf.Koanf, input BriefInput, regenerate bool) (Brief, error) {
+	key := briefKey(input, time.Now())
+	if !regenerate {
+		briefs.Lock()
+		brief, ok := briefs.m[key]
+		briefs.Unlock()
+		if ok {
+			log.Infof("Using the brief from %s", brief.GeneratedAt.Format(time.Kitchen))
+			return brief, nil
+		}
+	}
+
+	if len(input.News) == 0 {
+		return Brief{}, errors.New("there are no headlines to write a brief from yet")
+	}
+	if limit := config.Int("news.brief.max_headlines"); limit > 0 && len(input.News) > limit {
+		input.News = input.News[:limit]
+	}
+
+	provider, err := llm.FromConfig(config)
+	if err != nil {
+		return Brief{}, err
+	}
+	log.Infof("Writing a brief from %d headlines with %s", len(input.News), provider.Name())
+	text, err := provider.Generate(ctx, llm.Request{
+		Messages: []llm.Message{
+			{Role: llm.System, Content: briefPrompt},
+			{Role: llm.User, Content: briefData(input)},
+		},
+		Schema: briefSchema,
+	})
+	if err != nil {
+		return Brief{}, err
+	}
+
+	var response briefResponse
+	if err := json.Unmarshal([]byte(text), &response); err != nil {
+		log.Info(text)
+		return Brief{}, fmt.Errorf("%s's brief isn't valid JSON: %w", provider.Name(), err)
+	}
+
+	brief := Brief{
+		Macro:       BriefSection{Title: "Macro", Summary: response.Macro.Summary, Articles: articleIDs(input.News, response.Macro.Articles)},
+		Headlines:   len(input.News),
+		GeneratedAt: time.Now(),
+		GeneratedBy: provider.Name(),
+	}
+	for _, sector := range response.Sectors {
+		brief.Sectors = append(brief.Sectors, BriefSection{Title: sector.Name, Summary: sector.Summary, Articles: articleIDs(input.News, sector.Articles)})
+	}
+	for _, symbol := range response.Watchlist {
+		brief.Watchlist = append(brief.Watchlist, BriefSection{Title: strings.ToUpper(symbol.Symbol), Summary: symbol.Summary, Articles: articleIDs(input.News, symbol.Articles)})
+	}
+
+	briefs.Lock()
+	// briefs from earlier hours are never used again
+	for k, old := range briefs.m {
+		if !old.GeneratedAt.Truncate(time.Hour).Equal(brief.GeneratedAt.Truncate(time.Hour)) {
+			delete(briefs.m, k)
+		}
+	}
+	briefs.m[key] = brief
+	briefs.Unlock()
+	return brief, nil
+}
+
+// The headlines, quotes and commodities as sent to the model.
+func briefData(input BriefInput) string {
+	var b strings.Builder
+	b.WriteString("Headlines:\n")
+	for i, article := range input.News {
+		fmt.Fprintf(&b, "[%d] %s (%s, %s", i+1, article.Title, article.Source, article.PublicationDate.Format("Jan 2 15:04"))
+		if len(article.Tickers) > 0 {
+			fmt.Fprintf(&b, ", %s", strings.Join(article.Tickers, " "))
+		}
+		b.WriteString(")\n")
+		if len(article.Bullets) > 0 {
+			fmt.Fprintf(&b, "    %s\n", article.Bullets[0])
+		}
+	}
+
+	b.WriteString("\nWatchlist:\n")
+	if len(input.Quotes) == 0 {
+		b.WriteString("(empty)\n")
+	}
+	for _, quote := range input.Quotes {
+		fmt.Fprintf(&b, "%s %s: %.2f (%+.2f%% today)\n", quote.Symbol, quote.Name, quote.Price, quote.PercentChange)
+	}
+
+	b.WriteString("\nCommodities:\n")
+	for _, cmdty := range input.Commodities {
+		fmt.Fprintf(&b, "%s: %.2f (%+.2f%% today, %+.2f%% this week)\n", cmdty.Name, cmdty.Price, cmdty.OneDayMovement, cmdty.WeeklyMovement)
+	}
+	return b.String()
+}
+
+// The IDs of the numbered headlines, numbers the model made up are dropped.
+func articleIDs(news NewsUpdate, numbers []int) []string {
+	var ids []string
+	for _, n := range numbers {
+		if n < 1 || n > len(news) {
+			continue
+		}
+		if id := news[n-1].ID(); !slices.Contains(ids, id) {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
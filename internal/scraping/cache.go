@@ -0,0 +1,246 @@
+package scraping
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/charmbracelet/log"
+	"github.com/knadh/koanf/v2"
+)
+
+// Process-wide cache of scraped articles, shared by every session. Nil when
+// caching is disabled, the cache's methods are safe to call on nil.
+var Cache *ArticleCache
+
+// An article as it was scraped.
+type CachedArticle struct {
+	URL       string    `json:"url"`
+	Content   string    `json:"content"`
+	Bullets   []string  `json:"bullets"`
+	ScrapedAt time.Time `json:"scrapedAt"`
+	// which extractor the content came from
+	ExtractedBy string   `json:"extractedBy,omitempty"`
+	Author      string   `json:"author,omitempty"`
+	Tickers     []string `json:"tickers,omitempty"`
+}
+
+// What the cache knows about a file without reading it.
+type cacheEntry struct {
+	size      int64
+	scrapedAt time.Time
+}
+
+// Scraped articles saved on disk as one JSON file per URL, so an article is
+// only sent to Gemini once no matter how often or by how many sessions it's opened.
+type ArticleCache struct {
+	dir string
+	// entries older than this are scraped again, 0 keeps them forever
+	ttl time.Duration
+	// the oldest entries are removed past these limits, 0 for no limit
+	maxEntries int
+	maxBytes   int64
+
+	mu sync.Mutex
+	// every file in dir, keyed by file name
+	index map[string]cacheEntry
+}
+
+// Open the cache configured under news.cache, returns nil if it's disabled.
+func NewArticleCache(config *koanf.Koanf) (*ArticleCache, error) {
+	if !config.Bool("news.cache.enabled") {
+		return nil, nil
+	}
+
+	dir := config.String("news.cache.dir")
+	if dir == "" {
+		cacheDir, err := os.UserCacheDir()
+		if err != nil {
+			return nil, fmt.Errorf("cannot find cache directory: %w", err)
+		}
+		dir = filepath.Join(cacheDir, "gloom", "articles")
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("cannot create article cache: %w", err)
+	}
+
+	c := &ArticleCache{
+		dir:        dir,
+		ttl:        config.Duration("news.cache.ttl"),
+		maxEntries: config.Int("news.cache.max_entries"),
+		maxBytes:   config.Int64("news.cache.max_size_mb") * 1024 * 1024,
+		index:      make(map[string]cacheEntry),
+	}
+
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read article cache: %w", err)
+	}
+	for _, file := range files {
+		if file.IsDir() || filepath.Ext(file.Name()) != ".json" {
+			continue
+		}
+		info, err := file.Info()
+		if err != nil {
+			continue
+		}
+		// files are written when the article is scraped
+		c.index[file.Name()] = cacheEntry{size: info.Size(), scrapedAt: info.ModTime()}
+	}
+
+	c.mu.Lock()
+	c.evict(time.Now())
+	c.mu.Unlock()
+	log.Infof("Article cache at %s has %d articles", dir, len(c.index))
+	return c, nil
+}
+
+// File name for a URL, the same page linked with different tracking parameters shares a file.
+func cacheFileName(url string) string {
+	sum := sha1.Sum([]byte(NormalizeURL(url)))
+	return hex.EncodeToString(sum[:]) + ".json"
+}
+
+func (c *ArticleCache) expired(entry cacheEntry, now time.Time) bool {
+	return c.ttl > 0 && now.Sub(entry.scrapedAt) > c.ttl
+}
+
+// Get the cached article for url, false if it isn't cached or has expired.
+func (c *ArticleCache) Get(url string) (CachedArticle, bool) {
+	if c == nil || url == "" {
+		return CachedArticle{}, false
+	}
+	name := cacheFileName(url)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.index[name]
+	if !ok {
+		return CachedArticle{}, false
+	}
+	if c.expired(entry, time.Now()) {
+		c.remove(name)
+		return CachedArticle{}, false
+	}
+
+	content, err := os.ReadFile(filepath.Join(c.dir, name))
+	if err != nil {
+		log.Errorf("Cannot read cached article for %s: %v", url, err)
+		delete(c.index, name)
+		return CachedArticle{}, false
+	}
+	var article CachedArticle
+	if err := json.Unmarshal(content, &article); err != nil {
+		log.Errorf("Cannot parse cached article for %s, removing it: %v", url, err)
+		c.remove(name)
+		return CachedArticle{}, false
+	}
+	return article, true
+}
+
+// Save a scraped article, then trim the cache back under its limits.
+func (c *ArticleCache) Put(article CachedArticle) error {
+	if c == nil || article.URL == "" {
+		return nil
+	}
+	if article.ScrapedAt.IsZero() {
+		article.ScrapedAt = time.Now()
+	}
+	content, err := json.Marshal(article)
+	if err != nil {
+		return err
+	}
+	name := cacheFileName(article.URL)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	// write to a temporary file first so other sessions never read half an article
+	tmp, err := os.CreateTemp(c.dir, name+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("cannot write cached article: %w", err)
+	}
+	if _, err := tmp.Write(content); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return fmt.Errorf("cannot write cached article: %w", err)
+	}
+	tmp.Close()
+	if err := os.Rename(tmp.Name(), filepath.Join(c.dir, name)); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("cannot write cached article: %w", err)
+	}
+
+	c.index[name] = cacheEntry{size: int64(len(content)), scrapedAt: article.ScrapedAt}
+	c.evict(time.Now())
+	return nil
+}
+
+// Fill in an unreadable article from the cache, returns whether it was cached.
+func (c *ArticleCache) Fill(article *NewsArticle) bool {
+	if article.Readable {
+		return false
+	}
+	cached, ok := c.Get(article.URL)
+	if !ok {
+		return false
+	}
+	article.Content = cached.Content
+	article.Bullets = cached.Bullets
+	article.ExtractedBy = cached.ExtractedBy
+	article.Author = cached.Author
+	article.AddTickers(cached.Tickers)
+	article.Readable = true
+	return true
+}
+
+// Fill in every article and the other sources' versions of it.
+func (c *ArticleCache) FillAll(news []NewsArticle) {
+	if c == nil {
+		return
+	}
+	for i := range news {
+		c.Fill(&news[i])
+		for j := range news[i].Duplicates {
+			c.Fill(&news[i].Duplicates[j])
+		}
+	}
+}
+
+// Remove an entry and its file. Must be called with c.mu held.
+func (c *ArticleCache) remove(name string) {
+	delete(c.index, name)
+	if err := os.Remove(filepath.Join(c.dir, name)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		log.Errorf("Cannot remove cached article %s: %v", name, err)
+	}
+}
+
+// Remove expired entries, then the oldest ones until the cache is within its
+// limits. Must be called with c.mu held.
+func (c *ArticleCache) evict(now time.Time) {
+	var total int64
+	names := make([]string, 0, len(c.index))
+	for name, entry := range c.index {
+		if c.expired(entry, now) {
+			c.remove(name)
+			continue
+		}
+		names = append(names, name)
+		total += entry.size
+	}
+
+	slices.SortFunc(names, func(a, b string) int {
+		return c.index[a].scrapedAt.Compare(c.index[b].scrapedAt)
+	})
+	for len(names) > 0 && ((c.maxEntries > 0 && len(names) > c.maxEntries) || (c.maxBytes > 0 && total > c.maxBytes)) {
+		total -= c.index[names[0]].size
+		c.remove(names[0])
+		names = names[1:]
+	}
+}
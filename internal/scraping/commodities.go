@@ -1,11 +1,12 @@
 package scraping
 
 import (
+	"errors"
+	"fmt"
 	"strconv"
 	"strings"
 	"time"
 
-	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/log"
 	"github.com/gocolly/colly"
 )
@@ -19,19 +20,17 @@ type Commodity struct {
 
 type CommodityUpdateMsg []Commodity
 
-func CommodityUpdateTick() tea.Cmd {
-	return tea.Tick(5*time.Second, func(t time.Time) tea.Msg {
-		return GetCommodities()
-	})
-}
-
-func GetCommodities() tea.Msg {
+// Scrape the commodities table off tradingeconomics. Rows that can't be parsed
+// are skipped, an error is only returned if no commodity could be read.
+func GetCommodities() (CommodityUpdateMsg, error) {
 	// How many times we have retried to get the data
 	retries := 0
 	// Maximum amount of retries allowed
 	maxRetries := 3
 
 	var cmdtyData []Commodity
+	var fetchErr error
+	var rowErrs []error
 	// practice reading a news article
 	URL := "https://tradingeconomics.com/commodities"
 	c := colly.NewCollector(
@@ -59,7 +58,7 @@ func GetCommodities() tea.Msg {
 			log.Warnf("Retry %d/%d: %v", retries, maxRetries, err)
 			response.Request.Retry()
 		} else {
-			log.Error("Something went wrong: ", err)
+			fetchErr = err
 		}
 	})
 
@@ -71,40 +70,63 @@ func GetCommodities() tea.Msg {
 				cols = append(cols, strings.TrimSpace(col.Text))
 			})
 
-			cmtdtyName := strings.Split(cols[0], "  ")[0] // get only the commodity name, not the USD/symbol.
-
-			cols[1] = strings.ReplaceAll(cols[1], ",", "") // Remove comma for parsing number to float
-			price, err := strconv.ParseFloat(cols[1], 64)
-
+			cmdty, err := parseCommodityRow(cols)
 			if err != nil {
-				panic(err)
+				rowErrs = append(rowErrs, err)
+				return
 			}
-
-			cols[3] = strings.ReplaceAll(cols[3], "%", "")
-			oneDayMovement, err := strconv.ParseFloat(cols[3], 64)
-
-			if err != nil {
-				panic(err)
-			}
-
-			cols[4] = strings.ReplaceAll(cols[4], "%", "")
-			weeklyMovement, err := strconv.ParseFloat(cols[4], 64)
-
-			if err != nil {
-				panic(err)
-			}
-
-			cmdtyData = append(cmdtyData, Commodity{
-				Name:           cmtdtyName,
-				Price:          price,
-				OneDayMovement: oneDayMovement,
-				WeeklyMovement: weeklyMovement,
-			})
-
+			cmdtyData = append(cmdtyData, cmdty)
 		})
 	})
 
-	c.Visit(URL)
+	// retries run inside Visit, which still returns the first attempt's error if one succeeds,
+	// only fetchErr means every attempt failed
+	visitErr := c.Visit(URL)
+	if fetchErr != nil {
+		return nil, fmt.Errorf("cannot get commodities from %s: %w", URL, fetchErr)
+	}
+	if len(cmdtyData) == 0 {
+		if visitErr != nil {
+			return nil, fmt.Errorf("cannot get commodities from %s: %w", URL, visitErr)
+		}
+		if len(rowErrs) > 0 {
+			return nil, fmt.Errorf("cannot parse any commodity on %s: %w", URL, errors.Join(rowErrs...))
+		}
+		return nil, fmt.Errorf("found no commodities on %s", URL)
+	}
+	for _, err := range rowErrs {
+		log.Warnf("Skipping commodity: %v", err)
+	}
+	return CommodityUpdateMsg(cmdtyData), nil
+}
 
-	return CommodityUpdateMsg(cmdtyData)
+// A row of the commodities table: name, price, change, % change today, % change this week, ...
+func parseCommodityRow(cols []string) (Commodity, error) {
+	if len(cols) < 5 {
+		return Commodity{}, fmt.Errorf("row %q has %d columns, expected at least 5", cols, len(cols))
+	}
+
+	cmtdtyName := strings.Split(cols[0], "  ")[0] // get only the commodity name, not the USD/symbol.
+
+	price, err := strconv.ParseFloat(strings.ReplaceAll(cols[1], ",", ""), 64) // Remove comma for parsing number to float
+	if err != nil {
+		return Commodity{}, fmt.Errorf("%s: cannot parse price %q", cmtdtyName, cols[1])
+	}
+
+	oneDayMovement, err := strconv.ParseFloat(strings.ReplaceAll(cols[3], "%", ""), 64)
+	if err != nil {
+		return Commodity{}, fmt.Errorf("%s: cannot parse daily change %q", cmtdtyName, cols[3])
+	}
+
+	weeklyMovement, err := strconv.ParseFloat(strings.ReplaceAll(cols[4], "%", ""), 64)
+	if err != nil {
+		return Commodity{}, fmt.Errorf("%s: cannot parse weekly change %q", cmtdtyName, cols[4])
+	}
+
+	return Commodity{
+		Name:           cmtdtyName,
+		Price:          price,
+		OneDayMovement: oneDayMovement,
+		WeeklyMovement: weeklyMovement,
+	}, nil
 }
@@ -0,0 +1,196 @@
+package scraping
+
+import (
+	"cmp"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Query parameters that only track where a click came from, ones ending in _ are prefixes.
+var trackingParams = []string{"utm_", "guce_", "guccounter", "cmpid", "mod", "ncid", "fbclid", "gclid", "taid", "yptr"}
+
+func isTrackingParam(param string) bool {
+	param = strings.ToLower(param)
+	for _, tracking := range trackingParams {
+		if strings.HasSuffix(tracking, "_") && strings.HasPrefix(param, tracking) || param == tracking {
+			return true
+		}
+	}
+	return false
+}
+
+// Normalize a URL so the same page linked from different feeds compares equal.
+// The scheme, "www.", fragments, tracking parameters and trailing slashes are dropped.
+func NormalizeURL(raw string) string {
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil || u.Host == "" {
+		return strings.ToLower(strings.TrimSpace(raw))
+	}
+
+	query := u.Query()
+	for param := range query {
+		if isTrackingParam(param) {
+			query.Del(param)
+		}
+	}
+
+	host := strings.TrimPrefix(strings.ToLower(u.Host), "www.")
+	normalized := host + strings.TrimSuffix(u.EscapedPath(), "/")
+	if len(query) > 0 {
+		normalized += "?" + query.Encode()
+	}
+	return normalized
+}
+
+// Words that say nothing about what a headline is about.
+var stopWords = map[string]bool{
+	"a": true, "an": true, "the": true, "and": true, "or": true, "of": true, "to": true,
+	"in": true, "on": true, "for": true, "at": true, "by": true, "with": true, "as": true,
+	"is": true, "are": true, "was": true, "its": true, "it": true, "from": true, "after": true,
+	"says": true, "update": true,
+}
+
+// Normalize a headline, lower case without punctuation or a trailing " - Source".
+func NormalizeTitle(title string) string {
+	title = strings.ToLower(strings.TrimSpace(title))
+	// wire stories often end in the publisher's name
+	for _, sep := range []string{" - ", " | ", " — "} {
+		if i := strings.LastIndex(title, sep); i > len(title)/2 {
+			title = title[:i]
+		}
+	}
+
+	var b strings.Builder
+	for _, r := range title {
+		if unicode.IsLetter(r) || unicode.IsNumber(r) || r == '%' || r == '.' {
+			b.WriteRune(r)
+		} else {
+			b.WriteRune(' ')
+		}
+	}
+	return strings.Join(strings.Fields(b.String()), " ")
+}
+
+// The meaningful words of a headline.
+func titleTokens(title string) map[string]bool {
+	tokens := make(map[string]bool)
+	for _, word := range strings.Fields(NormalizeTitle(title)) {
+		word = strings.Trim(word, ".")
+		if word != "" && !stopWords[word] {
+			tokens[word] = true
+		}
+	}
+	return tokens
+}
+
+// Jaccard similarity of two token sets, the share of words they have in common.
+func jaccard(a, b map[string]bool) float64 {
+	if len(a) == 0 || len(b) == 0 {
+		return 0
+	}
+	var shared int
+	for token := range a {
+		if b[token] {
+			shared++
+		}
+	}
+	return float64(shared) / float64(len(a)+len(b)-shared)
+}
+
+// Stories further apart than this are never the same story.
+const clusterWindow = 48 * time.Hour
+
+type clusterMember struct {
+	article NewsArticle
+	url     string
+	title   string
+	tokens  map[string]bool
+}
+
+func (m clusterMember) same(o clusterMember, threshold float64) bool {
+	if d := m.article.PublicationDate.Sub(o.article.PublicationDate); d > clusterWindow || d < -clusterWindow {
+		return false
+	}
+	if m.article.URL != "" && m.url == o.url {
+		return true
+	}
+	if m.title == o.title {
+		return true
+	}
+	return jaccard(m.tokens, o.tokens) >= threshold
+}
+
+// Collapse duplicate and near duplicate articles into one. Articles are the
+// same story when they link the same page, have the same headline, or their
+// headlines share at least threshold of their words. The earliest article of
+// each story is kept, the others are put in its Duplicates, so the kept article
+// and its ID stay the same as more sources pick the story up.
+func ClusterArticles(news []NewsArticle, threshold float64) []NewsArticle {
+	// flatten articles that were already clustered
+	var flat []NewsArticle
+	seen := make(map[string]bool)
+	for _, article := range news {
+		for _, a := range append([]NewsArticle{article}, article.Duplicates...) {
+			a.Duplicates = nil
+			if id := a.ID(); !seen[id] {
+				seen[id] = true
+				flat = append(flat, a)
+			}
+		}
+	}
+
+	slices.SortStableFunc(flat, func(a, b NewsArticle) int {
+		if c := a.PublicationDate.Compare(b.PublicationDate); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.ID(), b.ID())
+	})
+
+	var clusters [][]clusterMember
+	for _, article := range flat {
+		member := clusterMember{
+			article: article,
+			url:     NormalizeURL(article.URL),
+			title:   NormalizeTitle(article.Title),
+			tokens:  titleTokens(article.Title),
+		}
+
+		placed := false
+		for i, cluster := range clusters {
+			if slices.ContainsFunc(cluster, func(m clusterMember) bool { return m.same(member, threshold) }) {
+				clusters[i] = append(cluster, member)
+				placed = true
+				break
+			}
+		}
+		if !placed {
+			clusters = append(clusters, []clusterMember{member})
+		}
+	}
+
+	var stories []NewsArticle
+	for _, cluster := range clusters {
+		story := cluster[0].article
+		for _, m := range cluster[1:] {
+			story.Duplicates = append(story.Duplicates, m.article)
+		}
+		stories = append(stories, story)
+	}
+
+	slices.SortStableFunc(stories, func(a, b NewsArticle) int {
+		return b.PublicationDate.Compare(a.PublicationDate)
+	})
+	return stories
+}
+
+// How many other sources carry the same story.
+func (a NewsArticle) OtherSources() int {
+	sources := map[string]bool{a.Source: true}
+	for _, d := range a.Duplicates {
+		sources[d.Source] = true
+	}
+	return len(sources) - 1
+}
@@ -0,0 +1,125 @@
+package scraping
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestNormalizeURL(t *testing.T) {
+	tests := []struct {
+		url  string
+		want string
+	}{
+		{"https://www.example.com/markets/fed-holds-rates/", "example.com/markets/fed-holds-rates"},
+		{"http://Example.com/Markets/Fed", "example.com/Markets/Fed"},
+		{"https://example.com/a#comments", "example.com/a"},
+		// tracking parameters go, the ones that pick the page stay
+		{"https://example.com/a?utm_source=rss&UTM_Medium=feed&id=3&mod=mw_rss", "example.com/a?id=3"},
+		{"https://example.com/a?guccounter=1&guce_referrer=abc", "example.com/a"},
+		{"https://example.com/a?model=x", "example.com/a?model=x"},
+		// not a URL, compared as is
+		{"  Not A URL ", "not a url"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			if got := NormalizeURL(tt.url); got != tt.want {
+				t.Errorf("NormalizeURL(%q) = %q, want %q", tt.url, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClusterArticles(t *testing.T) {
+	now := time.Date(2026, 10, 16, 18, 0, 0, 0, time.UTC)
+	article := func(title, url, source string, age time.Duration) NewsArticle {
+		return NewsArticle{Title: title, URL: url, Source: source, PublicationDate: now.Add(-age)}
+	}
+
+	fed := article("Fed holds rates steady, signals patience on cuts", "https://example.com/fed", "Market Wire", 3*time.Hour)
+	// shares 7 of its 8 words with fed
+	fedReuters := article("Fed holds rates steady, signals patience on rate cuts - Reuters", "https://reuters.com/fed", "Reuters", 2*time.Hour)
+	// shares 4 of the 9 words it and fed have between them
+	fedInflation := article("Fed holds rates steady as inflation cools", "https://example.net/fed", "Briefs", time.Hour)
+	// the same page as fed, linked with tracking parameters
+	fedTracked := article("Federal Reserve decision", "https://www.example.com/fed/?utm_source=rss", "Aggregator", 30*time.Minute)
+	oil := article("Oil slips as inventories build for a third week", "https://example.org/oil", "Atom Markets", 90*time.Minute)
+	// the same headline three days later is a different story
+	oilLater := article("Oil slips as inventories build for a third week", "https://example.org/oil-again", "Atom Markets", -70*time.Hour)
+
+	tests := []struct {
+		name      string
+		news      []NewsArticle
+		threshold float64
+		// titles of each story newest first, the kept article first
+		want [][]string
+	}{
+		{
+			name:      "similar headlines",
+			news:      []NewsArticle{fedReuters, oil, fed},
+			threshold: 0.6,
+			want:      [][]string{{oil.Title}, {fed.Title, fedReuters.Title}},
+		},
+		{
+			name:      "below the threshold",
+			news:      []NewsArticle{fedInflation, fed},
+			threshold: 0.6,
+			want:      [][]string{{fedInflation.Title}, {fed.Title}},
+		},
+		{
+			name:      "lower threshold",
+			news:      []NewsArticle{fedInflation, fed},
+			threshold: 0.4,
+			want:      [][]string{{fed.Title, fedInflation.Title}},
+		},
+		{
+			name:      "same page",
+			news:      []NewsArticle{fedTracked, fed},
+			threshold: 0.6,
+			want:      [][]string{{fed.Title, fedTracked.Title}},
+		},
+		{
+			name:      "same headline days apart",
+			news:      []NewsArticle{oil, oilLater},
+			threshold: 0.6,
+			want:      [][]string{{oilLater.Title}, {oil.Title}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stories := ClusterArticles(tt.news, tt.threshold)
+			var got [][]string
+			for _, story := range stories {
+				titles := []string{story.Title}
+				for _, d := range story.Duplicates {
+					titles = append(titles, d.Title)
+				}
+				got = append(got, titles)
+			}
+			if !slices.EqualFunc(got, tt.want, slices.Equal) {
+				t.Errorf("ClusterArticles = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClusterArticlesKeepsEarliestArticle(t *testing.T) {
+	now := time.Date(2026, 10, 16, 18, 0, 0, 0, time.UTC)
+	first := NewsArticle{Title: "NVIDIA shares climb after data center revenue beat", URL: "https://example.com/nvda", Source: "Market Wire", PublicationDate: now.Add(-2 * time.Hour)}
+	second := NewsArticle{Title: "NVIDIA shares climb after data center revenue beat", URL: "https://example.net/nvda", Source: "Briefs", PublicationDate: now.Add(-time.Hour)}
+	third := NewsArticle{Title: "NVIDIA shares climb after data center revenue beat", URL: "https://example.org/nvda", Source: "Atom Markets", PublicationDate: now}
+
+	stories := ClusterArticles([]NewsArticle{second, first}, 0.6)
+	if len(stories) != 1 || stories[0].ID() != first.ID() {
+		t.Fatalf("ClusterArticles kept %+v, want the earliest article", stories)
+	}
+
+	// clustering again with a later source keeps the same story and ID
+	again := ClusterArticles(append([]NewsArticle{third}, stories...), 0.6)
+	if len(again) != 1 || again[0].ID() != first.ID() {
+		t.Fatalf("ClusterArticles again kept %+v, want the earliest article", again)
+	}
+	if got := again[0].OtherSources(); got != 2 {
+		t.Errorf("OtherSources() = %d, want 2", got)
+	}
+}
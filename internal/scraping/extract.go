@@ -0,0 +1,374 @@
+package scraping
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf16"
+
+	"gloomberg/internal/llm"
+
+	"github.com/charmbracelet/log"
+	"github.com/knadh/koanf/v2"
+)
+
+// An article's text, extracted from its page.
+type ExtractedArticle struct {
+	Success bool     `json:"success"`
+	Title   string   `json:"title"`
+	Bullets []string `json:"bullets"`
+	Content string   `json:"content"`
+	Author  string   `json:"author"`
+	Tickers []string `json:"tickers"`
+	// when the article was published as RFC 3339, empty if the page doesn't say
+	Published string `json:"published"`
+}
+
+// When the article was published, zero if the page doesn't say.
+func (e ExtractedArticle) PublishedAt() time.Time {
+	published, _ := parsePublished(e.Published)
+	return published
+}
+
+// Models write the publication time as RFC 3339, some leave out the time of day.
+func parsePublished(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	if published, err := time.Parse(time.RFC3339, s); err == nil {
+		return published, nil
+	}
+	return time.Parse(time.DateOnly, s)
+}
+
+// Turns the HTML of an article's page into markdown.
+type ArticleExtractor interface {
+	// name shown while extracting, e.g. the model's name
+	Name() string
+	Extract(ctx context.Context, article NewsArticle, page []byte) (ExtractedArticle, error)
+}
+
+const extractPrompt = `
+You are a helpful AI assistant for webscraping.
+I will send you the HTML content of an news website, your job is to convert the article from HTML to markdown.
+Make sure you ONLY format the article, do not format the advertisements on the page or any of the article suggestions.
+Also please do not include the metadata in the content like the title, time of publication, or author,
+they have their own fields.
+Formatting should not just copy the text, but make use of the multitude of features that markdown offers,
+including matching <h1>-<h6> tags with their appropriate heading in markdown,
+along with rendering lists and tables, as well as anything else that can be properly represented in markdown.
+It should be noted that this text will be displayed in a terminal
+window, so you should not include any HTML entities in the outputted
+JSON, just format those entities into the characers/strings they represent.
+Format your responses in JSON like this:
+{
+	"success": true // whether or not you were able to successfully access and scrape the articles full contents
+	"title": <TITLE> // the article's headline
+	"bullets": []string // up to 5 bullet points summarizing the article
+	"content": <CONTENT> // the content of the article in a markdown formatted string
+	"author": <AUTHOR> // who wrote the article, "" if the page doesn't say
+	"tickers": []string // ticker symbols of the companies the article is about, e.g. ["AAPL"]
+	"published": <TIME> // when the article was published in RFC 3339, e.g. "2025-03-14T09:30:00-04:00", "" if the page doesn't say
+}
+`
+
+// The shape of the answer to extractPrompt.
+var articleSchema = &llm.Schema{
+	Type: llm.Object,
+	Properties: map[string]*llm.Schema{
+		"success":   {Type: llm.Boolean, Description: "whether the page has an article and its full text could be extracted"},
+		"title":     {Type: llm.String, Description: "the article's headline"},
+		"bullets":   {Type: llm.Array, Items: &llm.Schema{Type: llm.String}, Description: "up to 5 bullet points summarizing the article"},
+		"content":   {Type: llm.String, Description: "the article's text as markdown, without the title, author or publication time"},
+		"author":    {Type: llm.String, Description: "who wrote the article, empty if the page doesn't say"},
+		"tickers":   {Type: llm.Array, Items: &llm.Schema{Type: llm.String}, Description: "ticker symbols of the companies the article is about"},
+		"published": {Type: llm.String, Description: "when the article was published in RFC 3339, empty if the page doesn't say"},
+	},
+	Required: []string{"success", "title", "bullets", "content", "author", "tickers", "published"},
+}
+
+// Sent with the model's answer when it doesn't match the schema, so it can fix it.
+const repairPrompt = `Your answer was invalid: %s.
+Answer again with the corrected JSON only, in the same format.`
+
+// The model found no article on the page, asking again won't help.
+var errNoArticle = errors.New("couldn't find an article on the page")
+
+// Ticker symbols like NVDA or BRK.B
+var tickerPattern = regexp.MustCompile(`^[A-Z]{1,5}(?:\.[A-Z])?$`)
+
+// An extractor that can show the article while it's still being extracted.
+type StreamingExtractor interface {
+	ArticleExtractor
+	// Extract the article, calling partial with what's been extracted so far as it grows.
+	ExtractStream(ctx context.Context, article NewsArticle, page []byte, partial func(ExtractedArticle)) (ExtractedArticle, error)
+}
+
+// Creates an extractor that can be listed in news.extractors.
+type ExtractorFactory func(config *koanf.Koanf) (ArticleExtractor, error)
+
+var extractorTypes = map[string]ExtractorFactory{
+	"llm": func(config *koanf.Koanf) (ArticleExtractor, error) {
+		provider, err := llm.FromConfig(config)
+		if err != nil {
+			return nil, err
+		}
+		return &LLMExtractor{Provider: provider}, nil
+	},
+	"readability": func(*koanf.Koanf) (ArticleExtractor, error) {
+		return &ReadabilityExtractor{}, nil
+	},
+}
+
+// The extractors listed in news.extractors, in the order they're tried.
+// Extractors that can't be created are skipped.
+func ExtractorsFromConfig(config *koanf.Koanf) ([]ArticleExtractor, error) {
+	var extractors []ArticleExtractor
+	var errs []error
+	for _, name := range config.Strings("news.extractors") {
+		factory, ok := extractorTypes[name]
+		if !ok {
+			errs = append(errs, fmt.Errorf("unknown extractor %q", name))
+			continue
+		}
+		extractor, err := factory(config)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", name, err))
+			continue
+		}
+		extractors = append(extractors, extractor)
+	}
+	for _, err := range errs {
+		log.Errorf("Skipping article extractor: %s", err)
+	}
+	if len(extractors) == 0 && len(errs) == 0 {
+		return nil, errors.New("no article extractors in news.extractors")
+	} else if len(extractors) == 0 {
+		return nil, fmt.Errorf("no article extractors available: %w", errors.Join(errs...))
+	}
+	return extractors, nil
+}
+
+// Elements that are never part of the article, removed before the page is
+// sent to the model so it fits in smaller context windows.
+var pageNoise = []*regexp.Regexp{
+	regexp.MustCompile(`(?is)<script\b.*?</script>`),
+	regexp.MustCompile(`(?is)<style\b.*?</style>`),
+	regexp.MustCompile(`(?is)<noscript\b.*?</noscript>`),
+	regexp.MustCompile(`(?is)<svg\b.*?</svg>`),
+	regexp.MustCompile(`(?is)<iframe\b.*?</iframe>`),
+	regexp.MustCompile(`(?s)<!--.*?-->`),
+}
+
+// Extracts articles by asking a language model to rewrite the page as markdown.
+type LLMExtractor struct {
+	Provider llm.Provider
+}
+
+func (e *LLMExtractor) Name() string { return e.Provider.Name() }
+
+func (e *LLMExtractor) Extract(ctx context.Context, article NewsArticle, page []byte) (ExtractedArticle, error) {
+	return e.ExtractStream(ctx, article, page, nil)
+}
+
+func (e *LLMExtractor) ExtractStream(ctx context.Context, article NewsArticle, page []byte, partial func(ExtractedArticle)) (ExtractedArticle, error) {
+	for _, noise := range pageNoise {
+		page = noise.ReplaceAll(page, nil)
+	}
+
+	log.Infof("Sending %d bytes of %s to %s", len(page), article.URL, e.Provider.Name())
+	messages := []llm.Message{{Role: llm.User, Content: extractPrompt}}
+	text, err := e.generate(ctx, messages, page, partial)
+	if err != nil {
+		return ExtractedArticle{}, err
+	}
+	response, err := parseExtraction(text)
+	if err == nil || errors.Is(err, errNoArticle) {
+		return response, err
+	}
+
+	// one more try, telling the model what was wrong with its answer
+	log.Errorf("%s gave an invalid answer for %s, asking it to repair it: %s", e.Provider.Name(), article.URL, err)
+	log.Debug(text)
+	messages = append(messages,
+		llm.Message{Role: llm.Assistant, Content: text},
+		llm.Message{Role: llm.User, Content: fmt.Sprintf(repairPrompt, err)},
+	)
+	text, err = e.generate(ctx, messages, page, partial)
+	if err != nil {
+		return ExtractedArticle{}, fmt.Errorf("cannot repair its answer: %w", err)
+	}
+	response, err = parseExtraction(text)
+	if err != nil && !errors.Is(err, errNoArticle) {
+		log.Debug(text)
+		return ExtractedArticle{}, fmt.Errorf("%w, even after asking it to repair its answer", err)
+	}
+	return response, err
+}
+
+// Send the conversation with the page, streaming the answer to partial if it's set.
+func (e *LLMExtractor) generate(ctx context.Context, messages []llm.Message, page []byte, partial func(ExtractedArticle)) (string, error) {
+	req := llm.Request{
+		Messages:     messages,
+		Document:     page,
+		DocumentType: "text/html",
+		Schema:       articleSchema,
+	}
+	if partial == nil {
+		return e.Provider.Generate(ctx, req)
+	}
+	var streamed strings.Builder
+	return e.Provider.Stream(ctx, req, func(chunk string) {
+		streamed.WriteString(chunk)
+		// the answer is JSON, show the content as soon as it starts arriving
+		if content, ok := partialJSONString(streamed.String(), "content"); ok && content != "" {
+			partial(ExtractedArticle{Content: content, Bullets: partialJSONStrings(streamed.String(), "bullets")})
+		}
+	})
+}
+
+// Decode and validate the model's answer. Tickers and bullets that can't be
+// used are dropped, everything else that's wrong is an error the model can be asked to fix.
+func parseExtraction(text string) (ExtractedArticle, error) {
+	var response ExtractedArticle
+	if err := json.NewDecoder(strings.NewReader(text)).Decode(&response); err != nil {
+		var syntaxErr *json.SyntaxError
+		var typeErr *json.UnmarshalTypeError
+		switch {
+		case strings.TrimSpace(text) == "":
+			return ExtractedArticle{}, errors.New("the answer was empty")
+		case errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, io.EOF):
+			return ExtractedArticle{}, errors.New("the answer was cut off before the JSON ended")
+		case errors.As(err, &syntaxErr):
+			return ExtractedArticle{}, fmt.Errorf("the answer isn't valid JSON (%s at byte %d)", syntaxErr, syntaxErr.Offset)
+		case errors.As(err, &typeErr):
+			return ExtractedArticle{}, fmt.Errorf("%q should be a %s, not a %s", typeErr.Field, typeErr.Type, typeErr.Value)
+		}
+		return ExtractedArticle{}, fmt.Errorf("the answer doesn't match the format: %s", err)
+	}
+
+	if !response.Success {
+		return ExtractedArticle{}, errNoArticle
+	}
+	response.Content = strings.TrimSpace(response.Content)
+	if response.Content == "" {
+		return ExtractedArticle{}, errors.New(`the answer has no article text in "content"`)
+	}
+	if _, err := parsePublished(response.Published); err != nil {
+		return ExtractedArticle{}, fmt.Errorf(`"published" is %q, which isn't an RFC 3339 time`, response.Published)
+	}
+
+	response.Title = strings.TrimSpace(response.Title)
+	response.Author = strings.TrimSpace(response.Author)
+	bullets := response.Bullets[:0]
+	for _, bullet := range response.Bullets {
+		if bullet = strings.TrimSpace(bullet); bullet != "" && len(bullets) < 5 {
+			bullets = append(bullets, bullet)
+		}
+	}
+	response.Bullets = bullets
+	var tickers []string
+	for _, ticker := range response.Tickers {
+		ticker = strings.ToUpper(strings.TrimPrefix(strings.TrimSpace(ticker), "$"))
+		if tickerPattern.MatchString(ticker) && !slices.Contains(tickers, ticker) {
+			tickers = append(tickers, ticker)
+		}
+	}
+	response.Tickers = tickers
+	return response, nil
+}
+
+// Find where the value of key starts in JSON that may be incomplete.
+func partialJSONValue(text string, key string) (string, bool) {
+	i := strings.Index(text, `"`+key+`"`)
+	if i < 0 {
+		return "", false
+	}
+	rest := strings.TrimLeft(text[i+len(key)+2:], " \t\r\n")
+	rest, ok := strings.CutPrefix(rest, ":")
+	if !ok {
+		return "", false
+	}
+	return strings.TrimLeft(rest, " \t\r\n"), true
+}
+
+// The value of a string field in JSON that's still being written, as much of
+// it as has arrived. An escape sequence cut off at the end is left out.
+func partialJSONString(text string, key string) (string, bool) {
+	rest, ok := partialJSONValue(text, key)
+	if !ok {
+		return "", false
+	}
+	rest, ok = strings.CutPrefix(rest, `"`)
+	if !ok {
+		return "", false
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(rest); i++ {
+		switch c := rest[i]; c {
+		case '"':
+			return b.String(), true
+		case '\\':
+			if i+1 >= len(rest) {
+				return b.String(), true
+			}
+			i++
+			switch rest[i] {
+			case 'n':
+				b.WriteByte('\n')
+			case 't':
+				b.WriteByte('\t')
+			case 'r', 'b', 'f':
+			case 'u':
+				if i+4 >= len(rest) {
+					return b.String(), true
+				}
+				r, err := strconv.ParseUint(rest[i+1:i+5], 16, 32)
+				if err != nil {
+					return b.String(), true
+				}
+				i += 4
+				// characters outside the BMP are written as two escaped surrogates
+				if utf16.IsSurrogate(rune(r)) {
+					if i+6 >= len(rest) || rest[i+1:i+3] != `\u` {
+						return b.String(), true
+					}
+					low, err := strconv.ParseUint(rest[i+3:i+7], 16, 32)
+					if err != nil {
+						return b.String(), true
+					}
+					i += 6
+					r = uint64(utf16.DecodeRune(rune(r), rune(low)))
+				}
+				b.WriteRune(rune(r))
+			default:
+				// \", \\, \/ and stray escapes
+				b.WriteByte(rest[i])
+			}
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String(), true
+}
+
+// The value of a string array field in JSON that's still being written, nil until the array is complete.
+func partialJSONStrings(text string, key string) []string {
+	rest, ok := partialJSONValue(text, key)
+	if !ok || !strings.HasPrefix(rest, "[") {
+		return nil
+	}
+	var values []string
+	if err := json.NewDecoder(strings.NewReader(rest)).Decode(&values); err != nil {
+		return nil
+	}
+	return values
+}
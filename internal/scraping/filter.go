@@ -0,0 +1,141 @@
+package scraping
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// A search over the news, parsed from text like "fed source:Nasdaq since:2h".
+// Plain words must all appear in the title, source or content, the structured
+// filters narrow the results further.
+type NewsFilter struct {
+	// the text the filter was parsed from
+	Query string
+	// words that must appear, lower case
+	Words []string
+	// source:, any of these sources
+	Sources []string
+	// since:, published no longer ago than this
+	Since time.Duration
+	// ticker:, mentions any of these symbols
+	Tickers []string
+	// readable:, whether the article can be read without scraping, nil for either
+	Readable *bool
+}
+
+// Parse a filter query. Unknown "key:value" words are searched for as text.
+func ParseNewsFilter(query string) (NewsFilter, error) {
+	f := NewsFilter{Query: strings.TrimSpace(query)}
+	for _, field := range strings.Fields(query) {
+		key, value, ok := strings.Cut(field, ":")
+		if !ok || value == "" {
+			f.Words = append(f.Words, strings.ToLower(field))
+			continue
+		}
+
+		switch strings.ToLower(key) {
+		case "source":
+			f.Sources = append(f.Sources, strings.ToLower(value))
+		case "since":
+			d, err := parseAge(value)
+			if err != nil {
+				return NewsFilter{}, err
+			}
+			f.Since = d
+		case "ticker":
+			f.Tickers = append(f.Tickers, strings.ToUpper(strings.TrimPrefix(value, "$")))
+		case "readable":
+			switch strings.ToLower(value) {
+			case "yes", "y", "true":
+				readable := true
+				f.Readable = &readable
+			case "no", "n", "false":
+				readable := false
+				f.Readable = &readable
+			default:
+				return NewsFilter{}, fmt.Errorf("readable: takes yes or no, not %q", value)
+			}
+		default:
+			f.Words = append(f.Words, strings.ToLower(field))
+		}
+	}
+	return f, nil
+}
+
+// Parse an age like "30m", "2h", "1d" or "2w".
+func parseAge(value string) (time.Duration, error) {
+	if n, ok := strings.CutSuffix(value, "d"); ok {
+		days, err := strconv.Atoi(n)
+		if err == nil {
+			return time.Duration(days) * 24 * time.Hour, nil
+		}
+	}
+	if n, ok := strings.CutSuffix(value, "w"); ok {
+		weeks, err := strconv.Atoi(n)
+		if err == nil {
+			return time.Duration(weeks) * 7 * 24 * time.Hour, nil
+		}
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("since: takes an age like 30m, 2h or 1d, not %q", value)
+	}
+	return d, nil
+}
+
+// Whether the filter doesn't filter anything.
+func (f NewsFilter) Empty() bool {
+	return f.Query == ""
+}
+
+// Whether the article, or another source's version of it, matches the filter.
+func (f NewsFilter) Match(article NewsArticle, now time.Time) bool {
+	if f.Empty() {
+		return true
+	}
+	if f.matchOne(article, now) {
+		return true
+	}
+	for _, d := range article.Duplicates {
+		if f.matchOne(d, now) {
+			return true
+		}
+	}
+	return false
+}
+
+func (f NewsFilter) matchOne(article NewsArticle, now time.Time) bool {
+	if f.Since > 0 && now.Sub(article.PublicationDate) > f.Since {
+		return false
+	}
+	if f.Readable != nil && article.Readable != *f.Readable {
+		return false
+	}
+	if len(f.Sources) > 0 {
+		source := strings.ToLower(article.Source)
+		matched := false
+		for _, s := range f.Sources {
+			if strings.Contains(source, s) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	if len(f.Tickers) > 0 && !slices.ContainsFunc(f.Tickers, func(t string) bool { return slices.Contains(article.Tickers, t) }) {
+		return false
+	}
+
+	text := strings.ToLower(article.Title + " " + article.Source + " " + article.Content)
+	for _, word := range f.Words {
+		if !strings.Contains(text, word) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,546 @@
+package scraping
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// Extracts articles without a language model, by scoring the blocks of the
+// page on how much text they hold, like the browsers' reader modes do.
+// Always gives the same markdown for the same page and never leaves the machine.
+type ReadabilityExtractor struct{}
+
+func (e *ReadabilityExtractor) Name() string { return "readability" }
+
+// Elements that are never part of the article.
+var skippedElements = map[atom.Atom]bool{
+	atom.Script: true, atom.Style: true, atom.Noscript: true, atom.Svg: true, atom.Iframe: true,
+	atom.Nav: true, atom.Header: true, atom.Footer: true, atom.Aside: true, atom.Form: true,
+	atom.Button: true, atom.Input: true, atom.Select: true, atom.Textarea: true, atom.Template: true,
+	atom.Object: true, atom.Embed: true, atom.Canvas: true, atom.Dialog: true, atom.Img: true,
+	atom.Picture: true, atom.Video: true, atom.Audio: true, atom.Head: true,
+}
+
+var (
+	// class names and ids of ads, menus, comments and the like
+	unlikelyPattern = regexp.MustCompile(`(?i)\b(ad|ads|advert\w*|banner|breadcrumbs?|comments?|cookie\w*|disqus|footer|menu|modal|nav\w*|newsletter|outbrain|paywall|popup|promo\w*|related|share|sharing|sidebar|social|sponsor\w*|subscribe|subscription|taboola|tags|toolbar|widget)\b`)
+	// class names and ids of the article itself
+	likelyPattern = regexp.MustCompile(`(?i)\b(article\w*|body|content|entry|main|post|story|text)\b`)
+	// runs of whitespace, collapsed to a single space
+	spaces                 = regexp.MustCompile(`\s+`)
+	blankLines             = regexp.MustCompile(`\n{3,}`)
+	spaceBeforePunctuation = regexp.MustCompile(` ([.,;:!?)])`)
+)
+
+// Remove the elements that can't be part of the article, and those whose class
+// or id says they're ads, menus and the like.
+func stripNoise(n *html.Node) {
+	for c := n.FirstChild; c != nil; {
+		next := c.NextSibling
+		if c.Type == html.CommentNode || (c.Type == html.ElementNode && (skippedElements[c.DataAtom] || unlikely(c))) {
+			n.RemoveChild(c)
+		} else {
+			stripNoise(c)
+		}
+		c = next
+	}
+}
+
+func unlikely(n *html.Node) bool {
+	// the article is sometimes wrapped in elements with both kinds of names
+	if n.DataAtom == atom.Article || n.DataAtom == atom.Main || n.DataAtom == atom.Body {
+		return false
+	}
+	if hidden(n) {
+		return true
+	}
+	names := attr(n, "class") + " " + attr(n, "id") + " " + attr(n, "role")
+	return unlikelyPattern.MatchString(names) && !likelyPattern.MatchString(names)
+}
+
+func hidden(n *html.Node) bool {
+	style := strings.ReplaceAll(attr(n, "style"), " ", "")
+	return strings.Contains(style, "display:none") || attr(n, "aria-hidden") == "true" || hasAttr(n, "hidden")
+}
+
+func attr(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+func hasAttr(n *html.Node, key string) bool {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return true
+		}
+	}
+	return false
+}
+
+// All the text inside n, with whitespace collapsed.
+func textOf(n *html.Node) string {
+	var b strings.Builder
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.TextNode {
+			b.WriteString(n.Data)
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(n)
+	return strings.TrimSpace(spaces.ReplaceAllString(b.String(), " "))
+}
+
+// Share of n's text that's inside links, menus left over after stripping are mostly links.
+func linkDensity(n *html.Node) float64 {
+	text := len(textOf(n))
+	if text == 0 {
+		return 0
+	}
+	links := 0
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode && n.DataAtom == atom.A {
+			links += len(textOf(n))
+			return
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(n)
+	return float64(links) / float64(text)
+}
+
+// How likely an element is to hold the article going by its tag, class and id.
+func elementWeight(n *html.Node) float64 {
+	weight := 0.0
+	switch n.DataAtom {
+	case atom.Article:
+		weight += 25
+	case atom.Main, atom.Section:
+		weight += 10
+	case atom.Div:
+		weight += 5
+	case atom.Pre, atom.Td, atom.Blockquote:
+		weight += 3
+	case atom.Ol, atom.Ul, atom.Dl, atom.Form:
+		weight -= 3
+	case atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6, atom.Th:
+		weight -= 5
+	}
+	names := attr(n, "class") + " " + attr(n, "id")
+	if likelyPattern.MatchString(names) {
+		weight += 25
+	}
+	if unlikelyPattern.MatchString(names) {
+		weight -= 25
+	}
+	return weight
+}
+
+// Score every element on the paragraphs inside it and return the best one.
+// A paragraph adds to its parent's score, and half as much to its grandparent's.
+// Ties go to the candidate that comes first in the page.
+func bestCandidate(root *html.Node) (*html.Node, map[*html.Node]float64) {
+	scores := make(map[*html.Node]float64)
+	add := func(n *html.Node, score float64) {
+		if n == nil || n.Type != html.ElementNode {
+			return
+		}
+		if _, ok := scores[n]; !ok {
+			scores[n] = elementWeight(n)
+		}
+		scores[n] += score
+	}
+
+	// every element in document order, map order is random
+	var elements []*html.Node
+
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode && (n.DataAtom == atom.P || n.DataAtom == atom.Pre || n.DataAtom == atom.Td) {
+			text := textOf(n)
+			if len(text) >= 25 {
+				// longer paragraphs with more clauses are more likely to be the article
+				score := 1 + float64(strings.Count(text, ",")) + min(float64(len(text))/100, 3)
+				add(n.Parent, score)
+				if n.Parent != nil {
+					add(n.Parent.Parent, score/2)
+				}
+			}
+			return
+		}
+		if n.Type == html.ElementNode {
+			elements = append(elements, n)
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(root)
+
+	var best *html.Node
+	bestScore := 0.0
+	for _, n := range elements {
+		score, ok := scores[n]
+		if !ok {
+			continue
+		}
+		score *= 1 - linkDensity(n)
+		scores[n] = score
+		if best == nil || score > bestScore {
+			best, bestScore = n, score
+		}
+	}
+	return best, scores
+}
+
+// The best candidate and the siblings that look like they're part of the same
+// article, such as a lead paragraph outside the main block.
+func articleNodes(best *html.Node, scores map[*html.Node]float64) []*html.Node {
+	if best.Parent == nil {
+		return []*html.Node{best}
+	}
+	threshold := max(10, scores[best]*0.2)
+	var nodes []*html.Node
+	for s := best.Parent.FirstChild; s != nil; s = s.NextSibling {
+		if s == best {
+			nodes = append(nodes, s)
+			continue
+		}
+		if s.Type != html.ElementNode {
+			continue
+		}
+		if score, ok := scores[s]; ok && score >= threshold {
+			nodes = append(nodes, s)
+			continue
+		}
+		if s.DataAtom == atom.P {
+			text := textOf(s)
+			density := linkDensity(s)
+			if (len(text) > 80 && density < 0.25) || (len(text) > 0 && density == 0 && strings.Contains(text, ". ")) {
+				nodes = append(nodes, s)
+			}
+		}
+	}
+	return nodes
+}
+
+func (e *ReadabilityExtractor) Extract(ctx context.Context, article NewsArticle, page []byte) (ExtractedArticle, error) {
+	doc, err := html.Parse(bytes.NewReader(page))
+	if err != nil {
+		return ExtractedArticle{}, fmt.Errorf("cannot parse the page: %w", err)
+	}
+	stripNoise(doc)
+
+	best, scores := bestCandidate(doc)
+	if best == nil {
+		return ExtractedArticle{}, errors.New("couldn't find the article's text on the page")
+	}
+
+	base, _ := url.Parse(article.URL)
+	m := &markdownWriter{base: base, title: NormalizeTitle(article.Title)}
+	for _, n := range articleNodes(best, scores) {
+		m.block(n)
+	}
+	content := m.String()
+	if len(content) < 200 {
+		return ExtractedArticle{}, errors.New("couldn't find the article's text on the page")
+	}
+	return ExtractedArticle{Success: true, Content: content}, nil
+}
+
+// Writes HTML out as markdown for glamour.
+type markdownWriter struct {
+	b strings.Builder
+	// for links relative to the article
+	base *url.URL
+	// headings repeating the article's title are left out, it's shown above the article
+	title string
+	// nesting of the lists being written
+	lists []listState
+}
+
+type listState struct {
+	ordered bool
+	item    int
+}
+
+func (m *markdownWriter) String() string {
+	return strings.TrimSpace(blankLines.ReplaceAllString(m.b.String(), "\n\n"))
+}
+
+// Start a new block, separated from the last one by a blank line.
+func (m *markdownWriter) paragraph() {
+	if m.b.Len() > 0 {
+		m.b.WriteString("\n\n")
+	}
+}
+
+// Write a block level element.
+func (m *markdownWriter) block(n *html.Node) {
+	if n.Type == html.TextNode {
+		if text := strings.TrimSpace(spaces.ReplaceAllString(n.Data, " ")); text != "" {
+			m.paragraph()
+			m.b.WriteString(text)
+		}
+		return
+	}
+	if n.Type != html.ElementNode && n.Type != html.DocumentNode {
+		return
+	}
+
+	switch n.DataAtom {
+	case atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6:
+		text := m.inline(n)
+		if text == "" || NormalizeTitle(text) == m.title {
+			return
+		}
+		m.paragraph()
+		level := int(n.Data[1] - '0')
+		m.b.WriteString(strings.Repeat("#", level) + " " + text)
+	case atom.P:
+		if text := m.inline(n); text != "" {
+			m.paragraph()
+			m.b.WriteString(text)
+		}
+	case atom.Ul, atom.Ol:
+		m.list(n)
+	case atom.Blockquote:
+		inner := &markdownWriter{base: m.base, title: m.title}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			inner.block(c)
+		}
+		if quote := inner.String(); quote != "" {
+			m.paragraph()
+			m.b.WriteString("> " + strings.ReplaceAll(quote, "\n", "\n> "))
+		}
+	case atom.Pre:
+		m.paragraph()
+		m.b.WriteString("```\n" + strings.Trim(rawText(n), "\n") + "\n```")
+	case atom.Table:
+		m.table(n)
+	case atom.Hr:
+		m.paragraph()
+		m.b.WriteString("---")
+	case atom.Br:
+		m.b.WriteString("\n")
+	default:
+		// containers like div and section, whose children may be blocks or text
+		if !hasBlockChildren(n) {
+			if text := m.inline(n); text != "" {
+				m.paragraph()
+				m.b.WriteString(text)
+			}
+			return
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			m.block(c)
+		}
+	}
+}
+
+var blockElements = map[atom.Atom]bool{
+	atom.P: true, atom.Div: true, atom.Section: true, atom.Article: true, atom.Main: true,
+	atom.H1: true, atom.H2: true, atom.H3: true, atom.H4: true, atom.H5: true, atom.H6: true,
+	atom.Ul: true, atom.Ol: true, atom.Blockquote: true, atom.Pre: true, atom.Table: true,
+	atom.Hr: true, atom.Figure: true, atom.Figcaption: true, atom.Dl: true,
+}
+
+func hasBlockChildren(n *html.Node) bool {
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type == html.ElementNode && blockElements[c.DataAtom] {
+			return true
+		}
+	}
+	return false
+}
+
+// Text inside a pre block, keeping its whitespace.
+func rawText(n *html.Node) string {
+	var b strings.Builder
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.TextNode {
+			b.WriteString(n.Data)
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(n)
+	return b.String()
+}
+
+// Write the text inside n, with links and emphasis, on a single line.
+func (m *markdownWriter) inline(n *html.Node) string {
+	var b strings.Builder
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		switch {
+		case n.Type == html.TextNode:
+			b.WriteString(spaces.ReplaceAllString(n.Data, " "))
+			return
+		case n.Type != html.ElementNode:
+			return
+		}
+
+		switch n.DataAtom {
+		case atom.Br:
+			b.WriteString("\n")
+			return
+		case atom.Strong, atom.B, atom.Em, atom.I, atom.Code, atom.A:
+			inner := &markdownWriter{base: m.base}
+			text := inner.inline(n)
+			if text == "" {
+				return
+			}
+			switch n.DataAtom {
+			case atom.Strong, atom.B:
+				text = "**" + text + "**"
+			case atom.Em, atom.I:
+				text = "*" + text + "*"
+			case atom.Code:
+				text = "`" + text + "`"
+			case atom.A:
+				if href := m.link(attr(n, "href")); href != "" {
+					text = "[" + text + "](" + href + ")"
+				}
+			}
+			b.WriteString(" " + text + " ")
+			return
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		walk(c)
+	}
+
+	lines := strings.Split(b.String(), "\n")
+	for i, line := range lines {
+		line = spaces.ReplaceAllString(line, " ")
+		// the spaces added around emphasis shouldn't end up before punctuation
+		line = spaceBeforePunctuation.ReplaceAllString(line, "$1")
+		lines[i] = strings.TrimSpace(line)
+	}
+	return strings.TrimSpace(strings.Join(lines, "\n"))
+}
+
+// Absolute URL for a link on the page, empty for links that go nowhere useful.
+func (m *markdownWriter) link(href string) string {
+	href = strings.TrimSpace(href)
+	if href == "" || strings.HasPrefix(href, "#") || strings.HasPrefix(href, "javascript:") {
+		return ""
+	}
+	u, err := url.Parse(href)
+	if err != nil {
+		return ""
+	}
+	if m.base != nil {
+		u = m.base.ResolveReference(u)
+	}
+	return u.String()
+}
+
+func (m *markdownWriter) list(n *html.Node) {
+	if len(m.lists) == 0 {
+		m.paragraph()
+	}
+	m.lists = append(m.lists, listState{ordered: n.DataAtom == atom.Ol})
+	defer func() { m.lists = m.lists[:len(m.lists)-1] }()
+
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type != html.ElementNode || c.DataAtom != atom.Li {
+			continue
+		}
+		state := &m.lists[len(m.lists)-1]
+		state.item++
+		marker := "- "
+		if state.ordered {
+			marker = fmt.Sprintf("%d. ", state.item)
+		}
+		indent := strings.Repeat("  ", len(m.lists)-1)
+
+		// the item's own text, then any lists nested in it
+		var nested []*html.Node
+		item := &html.Node{Type: html.ElementNode, DataAtom: atom.Span, Data: "span"}
+		for gc := c.FirstChild; gc != nil; {
+			next := gc.NextSibling
+			if gc.Type == html.ElementNode && (gc.DataAtom == atom.Ul || gc.DataAtom == atom.Ol) {
+				nested = append(nested, gc)
+			} else {
+				c.RemoveChild(gc)
+				item.AppendChild(gc)
+			}
+			gc = next
+		}
+		text := strings.ReplaceAll(m.inline(item), "\n", " ")
+		if m.b.Len() > 0 && !strings.HasSuffix(m.b.String(), "\n\n") {
+			m.b.WriteString("\n")
+		}
+		m.b.WriteString(indent + marker + text)
+		for _, l := range nested {
+			m.list(l)
+		}
+	}
+}
+
+// Write a table, its first row is the header.
+func (m *markdownWriter) table(n *html.Node) {
+	var rows [][]string
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode && n.DataAtom == atom.Tr {
+			var row []string
+			for c := n.FirstChild; c != nil; c = c.NextSibling {
+				if c.Type == html.ElementNode && (c.DataAtom == atom.Td || c.DataAtom == atom.Th) {
+					cell := strings.ReplaceAll(m.inline(c), "\n", " ")
+					row = append(row, strings.ReplaceAll(cell, "|", `\|`))
+				}
+			}
+			if len(row) > 0 {
+				rows = append(rows, row)
+			}
+			return
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(n)
+	if len(rows) == 0 {
+		return
+	}
+
+	columns := 0
+	for _, row := range rows {
+		columns = max(columns, len(row))
+	}
+	m.paragraph()
+	for i, row := range rows {
+		for len(row) < columns {
+			row = append(row, "")
+		}
+		m.b.WriteString("| " + strings.Join(row, " | ") + " |\n")
+		if i == 0 {
+			m.b.WriteString(strings.Repeat("| --- ", columns) + "|\n")
+		}
+	}
+}
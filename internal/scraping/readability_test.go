@@ -0,0 +1,37 @@
+package scraping
+
+import (
+	"strings"
+	"testing"
+
+	"golang.org/x/net/html"
+)
+
+func TestBestCandidateBreaksTiesByDocumentOrder(t *testing.T) {
+	paragraph := "<p>A paragraph long enough to count, with a clause, and another clause for the score.</p>"
+	page := `<html><body>
+		<section><div id="first">` + strings.Repeat(paragraph, 3) + `</div></section>
+		<section><div id="second">` + strings.Repeat(paragraph, 3) + `</div></section>
+	</body></html>`
+
+	// map iteration order changes between runs, so try a few times
+	for range 50 {
+		doc, err := html.Parse(strings.NewReader(page))
+		if err != nil {
+			t.Fatal(err)
+		}
+		best, _ := bestCandidate(doc)
+		if best == nil {
+			t.Fatal("no candidate found")
+		}
+		var id string
+		for _, attr := range best.Attr {
+			if attr.Key == "id" {
+				id = attr.Val
+			}
+		}
+		if id != "first" {
+			t.Fatalf("best candidate is %q, want the first of the tied blocks", id)
+		}
+	}
+}
@@ -0,0 +1,357 @@
+package scraping
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"gloomberg/internal/llm"
+
+	"github.com/charmbracelet/log"
+	"github.com/knadh/koanf/v2"
+)
+
+// Which way a headline points the market.
+type Sentiment int
+
+const (
+	Bearish Sentiment = -1
+	Neutral Sentiment = 0
+	Bullish Sentiment = 1
+)
+
+func (s Sentiment) String() string {
+	switch s {
+	case Bullish:
+		return "bullish"
+	case Bearish:
+		return "bearish"
+	}
+	return "neutral"
+}
+
+// Parse "bullish", "bearish" or "neutral".
+func ParseSentiment(s string) (Sentiment, bool) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "bullish":
+		return Bullish, true
+	case "bearish":
+		return Bearish, true
+	case "neutral":
+		return Neutral, true
+	}
+	return Neutral, false
+}
+
+// How an article reads for the market.
+type ArticleScore struct {
+	Sentiment Sentiment
+	// how much the news could move prices, from 1 (minor) to 5 (market moving)
+	Impact int
+}
+
+// Scores the sentiment and impact of articles.
+type SentimentScorer interface {
+	// name shown in logs, e.g. "lexicon"
+	Name() string
+	// Score every article, in the same order.
+	Score(ctx context.Context, articles []NewsArticle) ([]ArticleScore, error)
+}
+
+// The scorer chosen with news.sentiment.scorer, "lexicon" unless the config says otherwise.
+func ScorerFromConfig(config *koanf.Koanf) SentimentScorer {
+	switch name := config.String("news.sentiment.scorer"); name {
+	case "", "lexicon":
+		return LexiconScorer{}
+	case "llm":
+		provider, err := llm.FromConfig(config)
+		if err != nil {
+			log.Errorf("Scoring headlines with the lexicon instead of the model: %s", err)
+			return LexiconScorer{}
+		}
+		return &LLMScorer{Provider: provider, scores: make(map[string]ArticleScore)}
+	default:
+		log.Errorf("Unknown sentiment scorer %q, using the lexicon", name)
+		return LexiconScorer{}
+	}
+}
+
+// Score every article and the other sources' versions of it. The articles are
+// copied, news may already have been sent to sessions. Articles the scorer
+// can't score are scored with the lexicon.
+func ScoreAll(ctx context.Context, scorer SentimentScorer, news []NewsArticle) []NewsArticle {
+	scored := slices.Clone(news)
+	// every version is scored in one batch
+	var all []*NewsArticle
+	for i := range scored {
+		scored[i].Duplicates = slices.Clone(scored[i].Duplicates)
+		all = append(all, &scored[i])
+		for j := range scored[i].Duplicates {
+			all = append(all, &scored[i].Duplicates[j])
+		}
+	}
+
+	articles := make([]NewsArticle, len(all))
+	for i, article := range all {
+		articles[i] = *article
+	}
+	scores, err := scorer.Score(ctx, articles)
+	if err != nil {
+		log.Errorf("Cannot score headlines with %s, using the lexicon: %s", scorer.Name(), err)
+		scores, _ = LexiconScorer{}.Score(ctx, articles)
+	}
+	for i, article := range all {
+		article.Sentiment = scores[i].Sentiment
+		article.Impact = scores[i].Impact
+	}
+	return scored
+}
+
+// Words and phrases that move a headline's sentiment, with how strongly.
+var sentimentLexicon = map[string]int{
+	// bullish
+	"beat": 2, "beats": 2, "tops": 2, "surge": 2, "surges": 2, "soar": 2, "soars": 2, "rally": 2, "rallies": 2,
+	"jump": 2, "jumps": 2, "record high": 2, "upgrade": 2, "upgrades": 2, "upgraded": 2, "outperform": 2,
+	"raises guidance": 2, "raises forecast": 2, "rate cut": 1, "rate cuts": 1, "buyback": 1, "gain": 1,
+	"gains": 1, "rise": 1, "rises": 1, "rose": 1, "climb": 1, "climbs": 1, "higher": 1, "strong": 1,
+	"growth": 1, "profit": 1, "boost": 1, "boosts": 1, "rebound": 1, "rebounds": 1, "optimism": 1,
+	"approval": 1, "approved": 1, "expands": 1, "bullish": 2, "easing": 1, "cools": 1,
+	// bearish
+	"miss": -2, "misses": -2, "plunge": -2, "plunges": -2, "tumble": -2, "tumbles": -2, "slump": -2,
+	"slumps": -2, "crash": -2, "sell-off": -2, "selloff": -2, "downgrade": -2, "downgrades": -2,
+	"downgraded": -2, "bankruptcy": -2, "default": -2, "fraud": -2, "recession": -2, "cuts guidance": -2,
+	"cuts forecast": -2, "profit warning": -2, "bearish": -2, "fall": -1, "falls": -1, "fell": -1,
+	"drop": -1, "drops": -1, "decline": -1, "declines": -1, "lower": -1, "slide": -1, "slides": -1,
+	"loss": -1, "losses": -1, "weak": -1, "weaker": -1, "warns": -1, "layoffs": -1, "lawsuit": -1,
+	"probe": -1, "investigation": -1, "fears": -1, "concern": -1, "concerns": -1, "tariff": -1,
+	"tariffs": -1, "inflation": -1, "hike": -1, "hikes": -1, "recall": -1, "delay": -1, "delays": -1,
+}
+
+// Words that make a headline more likely to move prices.
+var impactLexicon = map[string]int{
+	"fed": 1, "fomc": 1, "powell": 1, "ecb": 1, "rate": 1, "rates": 1, "inflation": 1, "cpi": 1,
+	"jobs report": 1, "payrolls": 1, "gdp": 1, "recession": 2, "tariff": 1, "tariffs": 1, "earnings": 1,
+	"guidance": 1, "merger": 2, "acquisition": 2, "acquire": 2, "buyout": 2, "bankruptcy": 2,
+	"default": 2, "crash": 2, "plunge": 1, "plunges": 1, "soar": 1, "soars": 1, "surge": 1, "surges": 1,
+	"record": 1, "sanctions": 1, "war": 1, "sec": 1, "antitrust": 1, "fraud": 2, "ceo": 1,
+}
+
+// Words that flip the sentiment of the word after them.
+var negations = []string{"not", "no", "never", "without", "fails", "failed", "avoid", "avoids"}
+
+var wordPattern = regexp.MustCompile(`[a-z0-9]+(?:[-'][a-z0-9]+)*`)
+
+// Scores headlines with word lists, without sending them anywhere.
+type LexiconScorer struct{}
+
+func (LexiconScorer) Name() string { return "lexicon" }
+
+func (l LexiconScorer) Score(_ context.Context, articles []NewsArticle) ([]ArticleScore, error) {
+	scores := make([]ArticleScore, len(articles))
+	for i, article := range articles {
+		scores[i] = l.score(article)
+	}
+	return scores, nil
+}
+
+func (LexiconScorer) score(article NewsArticle) ArticleScore {
+	// the headline says the most, bullets are a summary of the rest
+	text := strings.ToLower(article.Title + ". " + strings.Join(article.Bullets, ". "))
+	words := wordPattern.FindAllString(text, -1)
+
+	var polarity, impact int
+	for i := 0; i < len(words); i++ {
+		word := words[i]
+		// phrases take priority over their words
+		if i+1 < len(words) {
+			if _, ok := sentimentLexicon[word+" "+words[i+1]]; ok {
+				word += " " + words[i+1]
+			} else if _, ok := impactLexicon[word+" "+words[i+1]]; ok {
+				word += " " + words[i+1]
+			}
+		}
+		if weight, ok := sentimentLexicon[word]; ok {
+			if i > 0 && slices.Contains(negations, words[i-1]) {
+				weight = -weight
+			}
+			polarity += weight
+		}
+		impact += impactLexicon[word]
+		if strings.Contains(word, " ") {
+			i++
+		}
+	}
+
+	score := ArticleScore{Sentiment: Neutral, Impact: 1}
+	switch {
+	case polarity > 0:
+		score.Sentiment = Bullish
+	case polarity < 0:
+		score.Sentiment = Bearish
+	}
+	// strong wording and news about companies people follow matter more
+	score.Impact += min(impact, 2)
+	if polarity >= 3 || polarity <= -3 {
+		score.Impact++
+	}
+	if len(article.Tickers) > 0 || article.OtherSources() > 0 {
+		score.Impact++
+	}
+	score.Impact = min(score.Impact, 5)
+	return score
+}
+
+// A symbol's sentiment over its recent news.
+type TickerSentiment struct {
+	// from -1 (all bearish) to 1 (all bullish), weighted by impact
+	Score float64
+	// how many articles it's from
+	Articles int
+}
+
+// The sentiment of every symbol mentioned in articles published since the given time.
+func SentimentByTicker(articles []NewsArticle, since time.Time) map[string]TickerSentiment {
+	type total struct{ weighted, impact, articles int }
+	totals := make(map[string]total)
+	for _, article := range articles {
+		if article.Impact == 0 || article.PublicationDate.Before(since) {
+			continue
+		}
+		for _, symbol := range article.Tickers {
+			t := totals[symbol]
+			t.weighted += int(article.Sentiment) * article.Impact
+			t.impact += article.Impact
+			t.articles++
+			totals[symbol] = t
+		}
+	}
+
+	sentiments := make(map[string]TickerSentiment, len(totals))
+	for symbol, t := range totals {
+		sentiments[symbol] = TickerSentiment{Score: float64(t.weighted) / float64(t.impact), Articles: t.articles}
+	}
+	return sentiments
+}
+
+const sentimentPrompt = `You are a financial news analyst. For each numbered headline below, decide whether it is
+bullish, bearish or neutral for the companies or markets it's about, and rate its impact on prices
+from 1 (minor) to 5 (market moving). Answer in JSON like this:
+{ "scores": [{ "n": <headline number>, "sentiment": "bullish" | "bearish" | "neutral", "impact": <1-5> }] }
+`
+
+// The shape of the answer to sentimentPrompt.
+var sentimentSchema = &llm.Schema{
+	Type: llm.Object,
+	Properties: map[string]*llm.Schema{
+		"scores": {
+			Type: llm.Array,
+			Items: &llm.Schema{
+				Type: llm.Object,
+				Properties: map[string]*llm.Schema{
+					"n":         {Type: llm.Integer, Description: "the headline's number"},
+					"sentiment": {Type: llm.String, Enum: []string{"bullish", "bearish", "neutral"}},
+					"impact":    {Type: llm.Integer, Description: "from 1 (minor) to 5 (market moving)"},
+				},
+				Required: []string{"n", "sentiment", "impact"},
+			},
+		},
+	},
+	Required: []string{"scores"},
+}
+
+// How many headlines are sent to the model at a time.
+const sentimentBatchSize = 50
+
+// Scores headlines by asking a language model, in batches. Scores are kept
+// for as long as the article is in the news, so each headline is only sent once.
+type LLMScorer struct {
+	Provider llm.Provider
+
+	mu sync.Mutex
+	// scores by article ID
+	scores map[string]ArticleScore
+}
+
+func (s *LLMScorer) Name() string { return s.Provider.Name() }
+
+func (s *LLMScorer) Score(ctx context.Context, articles []NewsArticle) ([]ArticleScore, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var unscored []NewsArticle
+	for _, article := range articles {
+		if _, ok := s.scores[article.ID()]; !ok {
+			unscored = append(unscored, article)
+		}
+	}
+	for batch := range slices.Chunk(unscored, sentimentBatchSize) {
+		if err := s.scoreBatch(ctx, batch); err != nil {
+			return nil, err
+		}
+	}
+
+	// forget articles that are no longer in the news
+	current := make(map[string]bool, len(articles))
+	scores := make([]ArticleScore, len(articles))
+	for i, article := range articles {
+		id := article.ID()
+		current[id] = true
+		scores[i] = s.scores[id]
+	}
+	for id := range s.scores {
+		if !current[id] {
+			delete(s.scores, id)
+		}
+	}
+	return scores, nil
+}
+
+// Score the articles and remember their scores. Must be called with s.mu held.
+func (s *LLMScorer) scoreBatch(ctx context.Context, batch []NewsArticle) error {
+	var headlines strings.Builder
+	for i, article := range batch {
+		fmt.Fprintf(&headlines, "[%d] %s (%s)\n", i+1, article.Title, article.Source)
+	}
+	log.Infof("Scoring %d headlines with %s", len(batch), s.Provider.Name())
+	text, err := s.Provider.Generate(ctx, llm.Request{
+		Messages: []llm.Message{
+			{Role: llm.System, Content: sentimentPrompt},
+			{Role: llm.User, Content: headlines.String()},
+		},
+		Schema: sentimentSchema,
+	})
+	if err != nil {
+		return err
+	}
+
+	var response struct {
+		Scores []struct {
+			N         int    `json:"n"`
+			Sentiment string `json:"sentiment"`
+			Impact    int    `json:"impact"`
+		} `json:"scores"`
+	}
+	if err := json.Unmarshal([]byte(text), &response); err != nil {
+		log.Info(text)
+		return fmt.Errorf("%s's scores aren't valid JSON: %w", s.Provider.Name(), err)
+	}
+	for _, score := range response.Scores {
+		sentiment, ok := ParseSentiment(score.Sentiment)
+		if !ok || score.N < 1 || score.N > len(batch) {
+			continue
+		}
+		s.scores[batch[score.N-1].ID()] = ArticleScore{Sentiment: sentiment, Impact: max(1, min(score.Impact, 5))}
+	}
+	// headlines the model skipped are scored with the lexicon, and kept so they aren't sent again
+	for _, article := range batch {
+		if _, ok := s.scores[article.ID()]; !ok {
+			s.scores[article.ID()] = LexiconScorer{}.score(article)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,363 @@
+package scraping
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/charmbracelet/log"
+	"github.com/knadh/koanf/v2"
+	feed "github.com/mmcdole/gofeed"
+	"github.com/mmcdole/gofeed/atom"
+	jsonfeed "github.com/mmcdole/gofeed/json"
+	"github.com/mmcdole/gofeed/rss"
+)
+
+// Somewhere news articles come from.
+type NewsSource interface {
+	// Name shown in the news table's source column
+	Name() string
+	// What kind of news the source has, e.g. "markets" or "macro"
+	Category() string
+	Fetch(ctx context.Context) ([]NewsArticle, error)
+}
+
+// A source as declared in news.sources.
+type SourceConfig struct {
+	Type     string
+	Name     string
+	URL      string
+	Category string
+	Enabled  bool
+}
+
+// Creates a source from its config.
+type SourceFactory func(c SourceConfig) (NewsSource, error)
+
+// Every known source type, keyed by the type used in news.sources.
+var sourceTypes = map[string]SourceFactory{
+	"rss":              func(c SourceConfig) (NewsSource, error) { return &FeedSource{Format: RSSFormat, Config: c}, nil },
+	"atom":             func(c SourceConfig) (NewsSource, error) { return &FeedSource{Format: AtomFormat, Config: c}, nil },
+	"jsonfeed":         func(c SourceConfig) (NewsSource, error) { return &FeedSource{Format: JSONFormat, Config: c}, nil },
+	"tradingeconomics": func(c SourceConfig) (NewsSource, error) { return &TradingEconomicsSource{Config: c}, nil },
+}
+
+// Make a new source type available to news.sources.
+func RegisterSourceType(name string, factory SourceFactory) {
+	sourceTypes[name] = factory
+}
+
+// Create a source from its config.
+func NewSource(c SourceConfig) (NewsSource, error) {
+	factory, ok := sourceTypes[c.Type]
+	if !ok {
+		return nil, fmt.Errorf("unknown news source type %q", c.Type)
+	}
+	if c.URL == "" {
+		return nil, fmt.Errorf("news source %q has no url", c.Name)
+	}
+	return factory(c)
+}
+
+// Read the enabled sources from news.sources. Feeds in the older news.rss_feeds
+// list are added as RSS sources.
+func SourcesFromConfig(config *koanf.Koanf) []NewsSource {
+	var configs []SourceConfig
+	for _, c := range config.Slices("news.sources") {
+		configs = append(configs, SourceConfig{
+			Type:     c.String("type"),
+			Name:     c.String("name"),
+			URL:      c.String("url"),
+			Category: c.String("category"),
+			// sources are enabled unless they say otherwise
+			Enabled: !c.Exists("enabled") || c.Bool("enabled"),
+		})
+	}
+	for _, url := range config.Strings("news.rss_feeds") {
+		configs = append(configs, SourceConfig{Type: "rss", URL: url, Enabled: true})
+	}
+
+	var sources []NewsSource
+	seen := make(map[string]bool)
+	for _, c := range configs {
+		if !c.Enabled || seen[c.URL] {
+			continue
+		}
+		seen[c.URL] = true
+
+		source, err := NewSource(c)
+		if err != nil {
+			log.Errorf("Skipping news source: %v", err)
+			continue
+		}
+		sources = append(sources, source)
+	}
+	return sources
+}
+
+// Open a URL for reading, file:// URLs are read from disk so recorded
+// fixtures can stand in for a live source.
+func openURL(ctx context.Context, rawURL string, headers map[string]string) (io.ReadCloser, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme == "file" {
+		return os.Open(u.Path)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/135.0.0.0 Safari/537.36")
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= 300 {
+		resp.Body.Close()
+		return nil, fmt.Errorf("server returned %s", resp.Status)
+	}
+	return resp.Body, nil
+}
+
+// Formats a FeedSource can read.
+type FeedFormat int
+
+const (
+	RSSFormat FeedFormat = iota
+	AtomFormat
+	JSONFormat
+)
+
+// An RSS, Atom or JSON Feed.
+type FeedSource struct {
+	Format FeedFormat
+	Config SourceConfig
+	// title of the feed, used as the name when the config doesn't have one
+	title string
+}
+
+func (s *FeedSource) Name() string {
+	if s.Config.Name != "" {
+		return s.Config.Name
+	}
+	if s.title != "" {
+		return s.title
+	}
+	return s.Config.URL
+}
+
+func (s *FeedSource) Category() string { return s.Config.Category }
+
+func (s *FeedSource) Fetch(ctx context.Context) ([]NewsArticle, error) {
+	body, err := openURL(ctx, s.Config.URL, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+	return s.Parse(body)
+}
+
+// Parse a feed document in the source's format.
+func (s *FeedSource) Parse(r io.Reader) ([]NewsArticle, error) {
+	var parsed *feed.Feed
+	var err error
+	switch s.Format {
+	case RSSFormat:
+		var f *rss.Feed
+		if f, err = (&rss.Parser{}).Parse(r); err == nil {
+			parsed, err = (&feed.DefaultRSSTranslator{}).Translate(f)
+		}
+	case AtomFormat:
+		var f *atom.Feed
+		if f, err = (&atom.Parser{}).Parse(r); err == nil {
+			parsed, err = (&feed.DefaultAtomTranslator{}).Translate(f)
+		}
+	case JSONFormat:
+		var f *jsonfeed.Feed
+		if f, err = (&jsonfeed.Parser{}).Parse(r); err == nil {
+			parsed, err = (&feed.DefaultJSONTranslator{}).Translate(f)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	s.title = parsed.Title
+	var articles []NewsArticle
+	for _, item := range parsed.Items {
+		article := NewsArticle{
+			Title:    strings.TrimSpace(item.Title),
+			Source:   s.Name(),
+			Category: s.Category(),
+			Readable: false,
+			URL:      item.Link,
+		}
+		// not every feed dates its items
+		if item.PublishedParsed != nil {
+			article.PublicationDate = *item.PublishedParsed
+		} else if item.UpdatedParsed != nil {
+			article.PublicationDate = *item.UpdatedParsed
+		}
+		articles = append(articles, article)
+	}
+	return articles, nil
+}
+
+type TENewsJSON []struct {
+	ID          int         `json:"ID"`
+	Title       string      `json:"title"`
+	Description string      `json:"description"`
+	URL         string      `json:"url"`
+	Author      string      `json:"author"`
+	Country     string      `json:"country"`
+	Category    string      `json:"category"`
+	Image       interface{} `json:"image"`
+	Importance  int         `json:"importance"`
+	Date        string      `json:"date"`
+	Expiration  string      `json:"expiration"`
+	HTML        interface{} `json:"html"`
+	Type        interface{} `json:"type"`
+}
+
+// The TradingEconomics news stream, its articles are short enough to read
+// without scraping.
+type TradingEconomicsSource struct {
+	Config SourceConfig
+}
+
+func (s *TradingEconomicsSource) Name() string {
+	if s.Config.Name != "" {
+		return s.Config.Name
+	}
+	return "TradingEconomics"
+}
+
+func (s *TradingEconomicsSource) Category() string { return s.Config.Category }
+
+func (s *TradingEconomicsSource) Fetch(ctx context.Context) ([]NewsArticle, error) {
+	body, err := openURL(ctx, s.Config.URL, map[string]string{
+		"Accept":          "application/json, text/javascript, */*; q=0.01",
+		"Accept-Language": "en-US,en;q=0.6",
+		"Referer":         "https://tradingeconomics.com/stream",
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+	return s.Parse(body)
+}
+
+// Parse a page of the stream.
+func (s *TradingEconomicsSource) Parse(r io.Reader) ([]NewsArticle, error) {
+	var news TENewsJSON
+	if err := json.NewDecoder(r).Decode(&news); err != nil {
+		return nil, err
+	}
+
+	var articles []NewsArticle
+	for _, n := range news {
+		// TODO: Timestamps seem to be 4 hours ahead of EST, write code to account for this discrepancy
+		strippedTime := strings.Split(n.Date, ".")[0] // get rid of millisecond data, useless and causes errors
+		parsedTime, err := time.Parse("2006-01-02T15:04:05", strippedTime)
+		if err != nil {
+			log.Error("Cannot parse datetime for TradingEconomics article", "error: ", err)
+			continue
+		}
+
+		article := NewsArticle{
+			Title:           n.Title,
+			Source:          s.Name(),
+			Category:        s.Category(),
+			Readable:        true,
+			Content:         n.Description,
+			PublicationDate: parsedTime,
+		}
+		if strings.HasPrefix(n.URL, "/") {
+			article.URL = "https://tradingeconomics.com" + n.URL
+		} else {
+			article.URL = n.URL
+		}
+		articles = append(articles, article)
+	}
+	return articles, nil
+}
+
+// The outcome of fetching a single source.
+type SourceResult struct {
+	Source   NewsSource
+	Articles []NewsArticle
+	Err      error
+	// how long the fetch took
+	Latency   time.Duration
+	FetchedAt time.Time
+}
+
+// Fetch every source at the same time, giving each one at most timeout.
+// A source that fails or panics only fails its own result.
+func FetchSources(ctx context.Context, sources []NewsSource, timeout time.Duration) []SourceResult {
+	if timeout <= 0 {
+		timeout = 10 * time.Second
+	}
+
+	results := make([]SourceResult, len(sources))
+	var wg sync.WaitGroup
+	for i, source := range sources {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			start := time.Now()
+			defer func() {
+				if r := recover(); r != nil {
+					log.Errorf("News source %s panicked: %v", source.Name(), r)
+					results[i] = SourceResult{Source: source, Err: fmt.Errorf("panic: %v", r), Latency: time.Since(start), FetchedAt: start}
+				}
+			}()
+
+			fetchCtx, cancel := context.WithTimeout(ctx, timeout)
+			defer cancel()
+			articles, err := source.Fetch(fetchCtx)
+			if err != nil {
+				log.Errorf("Failed to get news from %s: %v", source.Name(), err)
+			} else {
+				log.Infof("Got %d articles from %s", len(articles), source.Name())
+			}
+			results[i] = SourceResult{
+				Source:    source,
+				Articles:  articles,
+				Err:       err,
+				Latency:   time.Since(start),
+				FetchedAt: start,
+			}
+		}()
+	}
+	wg.Wait()
+	return results
+}
+
+// Combine the articles of every successful result, newest first, with
+// duplicates collapsed by ClusterArticles.
+func MergeResults(results []SourceResult, threshold float64) NewsUpdate {
+	var news []NewsArticle
+	for _, result := range results {
+		if result.Err == nil {
+			news = append(news, result.Articles...)
+		}
+	}
+
+	return NewsUpdate(ClusterArticles(news, threshold))
+}
@@ -0,0 +1,129 @@
+package scraping
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// A file:// URL for a recorded fixture in testdata.
+func fixtureURL(t *testing.T, name string) string {
+	t.Helper()
+	path, err := filepath.Abs(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return "file://" + filepath.ToSlash(path)
+}
+
+func TestSourcesParseFixtures(t *testing.T) {
+	type want struct {
+		title string
+		url   string
+		date  time.Time
+	}
+	tests := []struct {
+		name string
+		// source config, URL is the fixture's file name
+		config SourceConfig
+		// the source's name once fetched
+		source string
+		want   []want
+	}{
+		{
+			name:   "rss",
+			config: SourceConfig{Type: "rss", URL: "rss.xml", Category: "markets"},
+			source: "Market Wire",
+			want: []want{
+				{"Fed holds rates steady, signals patience on cuts", "https://example.com/markets/fed-holds-rates", time.Date(2026, 10, 16, 18, 5, 0, 0, time.UTC)},
+				{"NVIDIA shares climb after data center revenue beat", "https://example.com/stocks/nvda-data-center", time.Date(2026, 10, 16, 16, 42, 0, 0, time.UTC)},
+				{"Undated item from a feed without publication dates", "https://example.com/misc/undated", time.Time{}},
+			},
+		},
+		{
+			name:   "atom",
+			config: SourceConfig{Type: "atom", URL: "atom.xml", Category: "energy"},
+			source: "Atom Markets",
+			want: []want{
+				{"Oil slips as inventories build for a third week", "https://example.org/energy/oil-inventories", time.Date(2026, 10, 16, 17, 30, 0, 0, time.UTC)},
+				// entries without a published date use their updated date
+				{"European stocks close higher led by banks", "https://example.org/europe/stocks-close", time.Date(2026, 10, 16, 15, 45, 0, 0, time.UTC)},
+			},
+		},
+		{
+			name:   "jsonfeed",
+			config: SourceConfig{Type: "jsonfeed", Name: "Briefs", URL: "feed.json", Category: "tech"},
+			source: "Briefs",
+			want: []want{
+				{"Apple unveils new chips, shares edge up", "https://example.net/tech/apple-chips", time.Date(2026, 10, 16, 14, 10, 0, 0, time.UTC)},
+				{"Treasury yields fall after soft retail sales", "https://example.net/rates/yields-fall", time.Date(2026, 10, 16, 12, 30, 0, 0, time.UTC)},
+			},
+		},
+		{
+			name:   "tradingeconomics",
+			config: SourceConfig{Type: "tradingeconomics", URL: "tradingeconomics.json", Category: "macro"},
+			source: "TradingEconomics",
+			want: []want{
+				{"US Retail Sales Unexpectedly Fall", "https://tradingeconomics.com/united-states/retail-sales", time.Date(2026, 10, 16, 12, 34, 0, 0, time.UTC)},
+				{"Euro Area Inflation Confirmed at 2.1%", "https://tradingeconomics.com/euro-area/inflation-cpi", time.Date(2026, 10, 16, 9, 0, 0, 0, time.UTC)},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := tt.config
+			config.URL = fixtureURL(t, config.URL)
+			source, err := NewSource(config)
+			if err != nil {
+				t.Fatalf("NewSource: %v", err)
+			}
+			articles, err := source.Fetch(context.Background())
+			if err != nil {
+				t.Fatalf("Fetch: %v", err)
+			}
+
+			if source.Name() != tt.source {
+				t.Errorf("Name() = %q, want %q", source.Name(), tt.source)
+			}
+			if len(articles) != len(tt.want) {
+				t.Fatalf("got %d articles, want %d", len(articles), len(tt.want))
+			}
+			for i, w := range tt.want {
+				got := articles[i]
+				if got.Title != w.title {
+					t.Errorf("article %d title = %q, want %q", i, got.Title, w.title)
+				}
+				if got.URL != w.url {
+					t.Errorf("article %d url = %q, want %q", i, got.URL, w.url)
+				}
+				if !got.PublicationDate.Equal(w.date) {
+					t.Errorf("article %d date = %s, want %s", i, got.PublicationDate, w.date)
+				}
+				if got.Category != config.Category {
+					t.Errorf("article %d category = %q, want %q", i, got.Category, config.Category)
+				}
+				if got.Source != tt.source {
+					t.Errorf("article %d source = %q, want %q", i, got.Source, tt.source)
+				}
+			}
+		})
+	}
+}
+
+func TestTradingEconomicsArticlesAreReadable(t *testing.T) {
+	source, err := NewSource(SourceConfig{Type: "tradingeconomics", URL: fixtureURL(t, "tradingeconomics.json")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	articles, err := source.Fetch(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, article := range articles {
+		if !article.Readable || article.Content == "" {
+			t.Errorf("%q should be readable from its description, got Readable %v and content %q", article.Title, article.Readable, article.Content)
+		}
+	}
+}
@@ -0,0 +1,149 @@
+package scraping
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/knadh/koanf/v2"
+)
+
+// Cashtags like $NVDA or $BRK.B
+var cashtagPattern = regexp.MustCompile(`\$([A-Z]{1,5}(?:\.[A-Z])?)\b`)
+
+// Words at the end of company names that headlines usually leave out.
+var companySuffixes = []string{
+	"inc", "incorporated", "corp", "corporation", "co", "company", "ltd", "limited",
+	"plc", "sa", "ag", "nv", "se", "holdings", "holding", "group", "platforms", "class a", "class b",
+}
+
+// Tags articles with the ticker symbols they mention. Symbols are found from
+// cashtags, the symbols themselves, company names learned from quotes, and
+// aliases from the config.
+type Tagger struct {
+	mu sync.RWMutex
+	// pattern for each name or alias, keyed by symbol
+	patterns map[string][]*regexp.Regexp
+	// names already added for each symbol, lower case
+	names map[string][]string
+}
+
+// Create a tagger with the aliases from news.ticker_aliases, a map of symbol to names.
+func NewTagger(config *koanf.Koanf) *Tagger {
+	t := &Tagger{
+		patterns: make(map[string][]*regexp.Regexp),
+		names:    make(map[string][]string),
+	}
+	for _, symbol := range config.MapKeys("news.ticker_aliases") {
+		for _, alias := range config.Strings("news.ticker_aliases." + symbol) {
+			t.addName(strings.ToUpper(symbol), alias)
+		}
+	}
+	return t
+}
+
+// Add a name for symbol, returns false if it was already known. Must be called with t.mu held.
+func (t *Tagger) addName(symbol string, name string) bool {
+	name = strings.TrimSpace(strings.TrimRight(name, ".,"))
+	// very short names match too much
+	if len(name) < 3 || slices.Contains(t.names[symbol], strings.ToLower(name)) {
+		return false
+	}
+	pattern, err := regexp.Compile(`(?i)(^|[^\pL\pN])` + regexp.QuoteMeta(name) + `($|[^\pL\pN])`)
+	if err != nil {
+		return false
+	}
+	t.names[symbol] = append(t.names[symbol], strings.ToLower(name))
+	t.patterns[symbol] = append(t.patterns[symbol], pattern)
+	return true
+}
+
+// Learn a company's name from its quote, e.g. "NVIDIA Corporation" tags
+// headlines mentioning NVIDIA with NVDA. Returns whether anything new was learned.
+func (t *Tagger) AddCompany(symbol string, shortName string) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	learned := false
+	// the symbol itself, written in upper case
+	marker := "$" + symbol
+	if !slices.Contains(t.names[symbol], marker) {
+		t.names[symbol] = append(t.names[symbol], marker)
+		// short symbols like "A" or "ON" are ordinary words too, those only match as cashtags
+		if len(symbol) >= 3 {
+			pattern := regexp.MustCompile(`(^|[^\pL\pN$])` + regexp.QuoteMeta(symbol) + `($|[^\pL\pN])`)
+			t.patterns[symbol] = append(t.patterns[symbol], pattern)
+			learned = true
+		}
+	}
+
+	if shortName == "" {
+		return learned
+	}
+	if t.addName(symbol, shortName) {
+		learned = true
+	}
+	if short := companyName(shortName); short != shortName && t.addName(symbol, short) {
+		learned = true
+	}
+	return learned
+}
+
+// Strip the legal suffixes off a company name, "Meta Platforms, Inc." becomes "Meta".
+func companyName(name string) string {
+	name = strings.TrimSpace(name)
+	for {
+		trimmed := strings.TrimRight(name, " .,")
+		lower := strings.ToLower(trimmed)
+		for _, suffix := range companySuffixes {
+			if strings.HasSuffix(lower, " "+suffix) {
+				trimmed = trimmed[:len(trimmed)-len(suffix)-1]
+				break
+			}
+		}
+		trimmed = strings.TrimSuffix(strings.TrimRight(trimmed, " .,"), ".com")
+		if trimmed == name {
+			return name
+		}
+		name = trimmed
+	}
+}
+
+// The symbols an article mentions, sorted.
+func (t *Tagger) Tag(article NewsArticle) []string {
+	text := article.Title + " " + article.Content
+
+	var tickers []string
+	for _, match := range cashtagPattern.FindAllStringSubmatch(text, -1) {
+		tickers = append(tickers, match[1])
+	}
+
+	t.mu.RLock()
+	for symbol, patterns := range t.patterns {
+		for _, pattern := range patterns {
+			if pattern.MatchString(text) {
+				tickers = append(tickers, symbol)
+				break
+			}
+		}
+	}
+	t.mu.RUnlock()
+
+	slices.Sort(tickers)
+	return slices.Compact(tickers)
+}
+
+// Tag every article and the other sources' versions of it. The articles are
+// copied, news may already have been sent to sessions.
+func (t *Tagger) TagAll(news []NewsArticle) []NewsArticle {
+	tagged := slices.Clone(news)
+	for i := range tagged {
+		tagged[i].Tickers = t.Tag(tagged[i])
+		tagged[i].Duplicates = slices.Clone(tagged[i].Duplicates)
+		for j := range tagged[i].Duplicates {
+			tagged[i].Duplicates[j].Tickers = t.Tag(tagged[i].Duplicates[j])
+		}
+	}
+	return tagged
+}
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	_ "embed"
 	"os"
+	"path/filepath"
 
 	"github.com/charmbracelet/log"
 	"github.com/knadh/koanf/parsers/json"
@@ -11,11 +12,6 @@ import (
 	"github.com/knadh/koanf/v2"
 )
 
-var (
-	// Config manager
-	Koanf *koanf.Koanf
-)
-
 //go:embed config/default.json
 var defaultConfig []byte
 
@@ -34,12 +30,41 @@ func StripCommentsFromJSON(fileContent []byte) ([]byte, error) {
 	return bytes.Join(filteredLines, []byte("\n")), nil
 }
 
+// Directory gloom keeps its config and state files in, ~/.config/gloom
+func ConfigDir() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".config", "gloom"), nil
+}
+
+// Create a new config manager with the default config, overridden by the
+// user's config file if it exists.
+func LoadConfig() *koanf.Koanf {
+	k := koanf.New(".")
+	LoadDefaultConfig(k)
+
+	configDir, err := ConfigDir()
+	if err != nil {
+		log.Errorf("Error ocurred while loading config file path: %v", err)
+		return k
+	}
+
+	configFilePath := filepath.Join(configDir, "config.json")
+	log.Infof("Checking for config file at path %s", configFilePath)
+
+	// Check if user config file exists
+	if _, err := os.Stat(configFilePath); err == nil {
+		log.Infof("Config file found at %s, loading...", configFilePath)
+		LoadUserConfig(k, configFilePath)
+	}
+	return k
+}
+
 // Loads the user defined config.
-func LoadUserConfig(path string) {
+func LoadUserConfig(k *koanf.Koanf, path string) {
 	log.Debug("Loading configuration at %s", path)
-	if Koanf == nil {
-		Koanf = koanf.New(".")
-	}
 
 	fileContent, err := os.ReadFile(path)
 	if err != nil {
@@ -53,25 +78,21 @@ func LoadUserConfig(path string) {
 		return
 	}
 
-	if err := Koanf.Load(rawbytes.Provider(sanitizedJSON), json.Parser()); err != nil {
+	if err := k.Load(rawbytes.Provider(sanitizedJSON), json.Parser()); err != nil {
 		log.Fatalf("Error occurred while loading config: %v", err)
 	}
 	log.Info("Loaded user config file")
 }
 
 // Loads the default user config
-func LoadDefaultConfig() {
-	if Koanf == nil {
-		Koanf = koanf.New(".")
-	}
-
+func LoadDefaultConfig(k *koanf.Koanf) {
 	sanitizedJSON, err := StripCommentsFromJSON(defaultConfig)
 	if err != nil {
 		log.Warnf("Unable to read user config file, %v", err)
 		return
 	}
 
-	err = Koanf.Load(rawbytes.Provider(sanitizedJSON), json.Parser())
+	err = k.Load(rawbytes.Provider(sanitizedJSON), json.Parser())
 	if err != nil {
 		log.Fatalf("Error loading default config %v", err)
 	}
@@ -3,8 +3,6 @@ package utils
 
 import (
 	tea "github.com/charmbracelet/bubbletea"
-	"github.com/charmbracelet/lipgloss"
-	"github.com/charmbracelet/log"
 
 	"github.com/charmbracelet/glamour/ansi"
 )
@@ -28,6 +26,10 @@ type OpenPromptMsg string
 type PromptOpenMsg struct {
 	Prompt       string
 	CallbackFunc func(string) tea.Msg
+	// text the prompt starts with
+	Value string
+	// optional, called every time the text changes, and with Value again if the prompt is cancelled
+	ChangeFunc func(string) tea.Msg
 }
 
 type KeyBinding struct {
@@ -45,12 +47,6 @@ func boolPtr(b bool) *bool       { return &b }
 func stringPtr(s string) *string { return &s }
 func uintPtr(u uint) *uint       { return &u }
 
-var (
-	UserLog  *log.Logger
-	Program  *tea.Program
-	Renderer *lipgloss.Renderer
-)
-
 // Modified Code from https://github.com/charmbracelet/glamour/blob/05e1d5e15ff0d26d8c0301191b9ee0e67524160a/styles/styles.go
 
 const (
@@ -59,8 +55,8 @@ const (
 	defaultMargin          = 2
 )
 
-// Returns an ansi.StyleConfig object to be used with Glamour renderers, customized to the users config.
-func CreateMarkdownUserConfig() ansi.StyleConfig {
+// Returns an ansi.StyleConfig object to be used with Glamour renderers, customized to the users accent color.
+func CreateMarkdownUserConfig(accentColor string) ansi.StyleConfig {
 	var UserMarkdownConfig = ansi.StyleConfig{
 		Document: ansi.StyleBlock{
 			StylePrimitive: ansi.StylePrimitive{
@@ -72,7 +68,7 @@ func CreateMarkdownUserConfig() ansi.StyleConfig {
 		},
 		BlockQuote: ansi.StyleBlock{
 			StylePrimitive: ansi.StylePrimitive{
-				Color:  stringPtr(Koanf.String("theme.accentColor")),
+				Color:  stringPtr(accentColor),
 				Italic: boolPtr(true),
 			},
 			Indent: uintPtr(defaultMargin),
@@ -88,13 +84,13 @@ func CreateMarkdownUserConfig() ansi.StyleConfig {
 		Heading: ansi.StyleBlock{
 			StylePrimitive: ansi.StylePrimitive{
 				BlockSuffix: "\n",
-				Color:       stringPtr(Koanf.String("theme.accentColor")),
+				Color:       stringPtr(accentColor),
 				Bold:        boolPtr(true),
 			},
 		},
 		H1: ansi.StyleBlock{
 			StylePrimitive: ansi.StylePrimitive{
-				BackgroundColor: stringPtr(Koanf.String("theme.accentColor")),
+				BackgroundColor: stringPtr(accentColor),
 				Color:           stringPtr("#F8F8F2"),
 				Bold:            boolPtr(true),
 			},
@@ -128,7 +124,7 @@ func CreateMarkdownUserConfig() ansi.StyleConfig {
 			CrossedOut: boolPtr(true),
 		},
 		Emph: ansi.StylePrimitive{
-			Color:  stringPtr(Koanf.String("theme.accentColor")),
+			Color:  stringPtr(accentColor),
 			Italic: boolPtr(true),
 		},
 		Strong: ansi.StylePrimitive{
@@ -248,7 +244,7 @@ func CreateMarkdownUserConfig() ansi.StyleConfig {
 					Color: stringPtr("#ff5555"),
 				},
 				GenericEmph: ansi.StylePrimitive{
-					Color:  stringPtr(Koanf.String("theme.accentColor")),
+					Color:  stringPtr(accentColor),
 					Italic: boolPtr(true),
 				},
 				GenericInserted: ansi.StylePrimitive{
@@ -0,0 +1,43 @@
+package utils
+
+import (
+	"slices"
+
+	"github.com/knadh/koanf/v2"
+)
+
+// A news filter saved under a name.
+type NewsView struct {
+	Name   string `json:"name"`
+	Filter string `json:"filter"`
+}
+
+// The saved news views, the ones in news.views followed by the ones the user
+// saved. A saved view replaces the config view with the same name.
+func (s *UserState) NewsViews(config *koanf.Koanf) []NewsView {
+	var views []NewsView
+	for _, v := range config.Slices("news.views") {
+		if name := v.String("name"); name != "" {
+			views = append(views, NewsView{Name: name, Filter: v.String("filter")})
+		}
+	}
+	for _, saved := range s.SavedNewsViews {
+		i := slices.IndexFunc(views, func(v NewsView) bool { return v.Name == saved.Name })
+		if i >= 0 {
+			views[i] = saved
+		} else {
+			views = append(views, saved)
+		}
+	}
+	return views
+}
+
+// Save a news view, replacing the saved view with the same name.
+func (s *UserState) SaveNewsView(view NewsView) {
+	i := slices.IndexFunc(s.SavedNewsViews, func(v NewsView) bool { return v.Name == view.Name })
+	if i >= 0 {
+		s.SavedNewsViews[i] = view
+		return
+	}
+	s.SavedNewsViews = append(s.SavedNewsViews, view)
+}
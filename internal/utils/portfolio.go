@@ -0,0 +1,60 @@
+package utils
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/knadh/koanf/v2"
+)
+
+// A holding in the user's portfolio.
+type Position struct {
+	Symbol   string  `json:"symbol"`
+	Quantity float64 `json:"quantity"`
+	// Average price paid per share
+	CostBasis float64 `json:"costBasis"`
+	// Currency the position is held in, defaults to the quote's currency
+	Currency string `json:"currency"`
+}
+
+// Path of the positions file, portfolio.positions_file or ~/.config/gloom/positions.json
+func PositionsPath(config *koanf.Koanf) (string, error) {
+	if path := config.String("portfolio.positions_file"); path != "" {
+		return path, nil
+	}
+	configDir, err := ConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(configDir, "positions.json"), nil
+}
+
+// Read the positions file, a missing file is an empty portfolio.
+func LoadPositions(path string) ([]Position, error) {
+	content, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("cannot read positions file: %w", err)
+	}
+
+	// positions files can have comments, same as the config
+	sanitizedJSON, err := StripCommentsFromJSON(content)
+	if err != nil {
+		return nil, err
+	}
+
+	var positions []Position
+	if err := json.Unmarshal(sanitizedJSON, &positions); err != nil {
+		return nil, fmt.Errorf("cannot parse positions file %s: %w", path, err)
+	}
+
+	for i := range positions {
+		positions[i].Symbol = strings.ToUpper(positions[i].Symbol)
+	}
+	return positions, nil
+}
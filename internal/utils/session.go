@@ -0,0 +1,68 @@
+package utils
+
+import (
+	"sync/atomic"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/log"
+	"github.com/knadh/koanf/v2"
+)
+
+// Everything that belongs to a single user session. Each SSH connection (or
+// the local terminal) gets its own Session so sessions never share a program,
+// renderer, log or config.
+type Session struct {
+	// The program running this session, set with SetProgram once it's created.
+	// Atomic because the hub sends to it from its own goroutines.
+	program atomic.Pointer[tea.Program]
+	// use instead of lipgloss.NewStyle()
+	Renderer *lipgloss.Renderer
+	// Log for everything that happens in this session
+	Log *log.Logger
+	// Config manager
+	Config *koanf.Koanf
+	// State that is saved between runs, such as the watchlist
+	State *UserState
+	// Where State is saved, empty if the session's state shouldn't be saved
+	StatePath string
+}
+
+// Load the state file at path into the session.
+func (s *Session) LoadState(path string) {
+	s.StatePath = path
+	state, err := LoadState(path)
+	if err != nil {
+		s.Log.Errorf("Cannot load user state, starting fresh: %v", err)
+	}
+	s.State = state
+}
+
+// Write the session's state back to disk.
+func (s *Session) SaveState() {
+	if s.StatePath == "" {
+		s.Log.Info("No state file for this session, not saving state")
+		return
+	}
+	if err := s.State.Save(s.StatePath); err != nil {
+		s.Log.Errorf("Cannot save user state: %v", err)
+		return
+	}
+	s.Log.Infof("Saved user state to %s", s.StatePath)
+}
+
+// Set the program running this session.
+func (s *Session) SetProgram(program *tea.Program) {
+	s.program.Store(program)
+}
+
+// Send a message to the session's program, messages sent before the program
+// is created are dropped.
+func (s *Session) Send(msg tea.Msg) {
+	program := s.program.Load()
+	if program == nil {
+		s.Log.Warnf("Dropping %T, session has no program yet", msg)
+		return
+	}
+	program.Send(msg)
+}
@@ -0,0 +1,116 @@
+package utils
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"gloomberg/internal/alerts"
+)
+
+// State that gloom writes itself and keeps between runs. It lives in its own
+// file so the hand-edited config.json never gets rewritten.
+type UserState struct {
+	// Watchlists the user has edited, each replaces the watchlist with the same name from the config
+	Watchlists []*Watchlist `json:"watchlists,omitempty"`
+	// Name of the watchlist that was open last
+	ActiveWatchlist string `json:"activeWatchlist,omitempty"`
+	// Price alerts
+	Alerts []alerts.Alert `json:"alerts,omitempty"`
+	// News filters the user saved, see NewsViews
+	SavedNewsViews []NewsView `json:"newsViews,omitempty"`
+
+	// Older state files only had a single watchlist, it is moved into Watchlists when loaded.
+	Watchlist *Watchlist `json:"watchlist,omitempty"`
+}
+
+// Save an edited watchlist, replacing the saved watchlist with the same name.
+func (s *UserState) SetWatchlist(list *Watchlist) {
+	for i, saved := range s.Watchlists {
+		if saved.Name == list.Name {
+			s.Watchlists[i] = list
+			return
+		}
+	}
+	s.Watchlists = append(s.Watchlists, list)
+}
+
+// Merge the saved watchlists on top of the ones from the config. Saved lists
+// replace config lists with the same name, lists that only exist in the state come last.
+func (s *UserState) MergeWatchlists(lists []*Watchlist) []*Watchlist {
+	merged := slices.Clone(lists)
+	for _, saved := range s.Watchlists {
+		i := slices.IndexFunc(merged, func(w *Watchlist) bool { return w.Name == saved.Name })
+		if i >= 0 {
+			merged[i] = saved
+		} else {
+			merged = append(merged, saved)
+		}
+	}
+	return merged
+}
+
+// Path of the state file. Local sessions use ~/.config/gloom/state.json, SSH
+// sessions pass the fingerprint of the user's public key to get their own file.
+func StatePath(fingerprint string) (string, error) {
+	configDir, err := ConfigDir()
+	if err != nil {
+		return "", err
+	}
+	if fingerprint == "" {
+		return filepath.Join(configDir, "state.json"), nil
+	}
+	return filepath.Join(configDir, "users", fingerprint, "state.json"), nil
+}
+
+// Read the state file at path, a missing file is an empty state.
+func LoadState(path string) (*UserState, error) {
+	state := &UserState{}
+	content, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return state, nil
+	} else if err != nil {
+		return state, fmt.Errorf("cannot read state file: %w", err)
+	}
+
+	if err := json.Unmarshal(content, state); err != nil {
+		return &UserState{}, fmt.Errorf("cannot parse state file %s: %w", path, err)
+	}
+
+	if state.Watchlist != nil {
+		state.SetWatchlist(state.Watchlist)
+		state.Watchlist = nil
+	}
+	return state, nil
+}
+
+// Write the state to path, the file is replaced in one step so a crash
+// halfway through never leaves a truncated state file behind.
+func (s *UserState) Save(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("cannot create state directory: %w", err)
+	}
+
+	content, err := json.MarshalIndent(s, "", "\t")
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".state-*.json")
+	if err != nil {
+		return fmt.Errorf("cannot create state file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(content); err != nil {
+		tmp.Close()
+		return fmt.Errorf("cannot write state file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("cannot write state file: %w", err)
+	}
+	return os.Rename(tmp.Name(), path)
+}
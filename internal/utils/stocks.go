@@ -1,14 +1,212 @@
 package utils
 
 import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/charmbracelet/log"
+	"github.com/knadh/koanf/v2"
 	"github.com/piquette/finance-go"
 	"github.com/piquette/finance-go/equity"
 )
 
-func GetCurrentOHLCV(symbol string) (finance.Equity, error) {
-	q, err := equity.Get(symbol)
+// A snapshot of a single symbol, independent of whichever provider produced it.
+type Quote struct {
+	Symbol          string  `json:"symbol"`
+	ShortName       string  `json:"shortName"`
+	Currency        string  `json:"currency"`
+	Price           float64 `json:"price"`
+	PreviousClose   float64 `json:"previousClose"`
+	Change          float64 `json:"change"`
+	ChangePercent   float64 `json:"changePercent"`
+	FiftyDayAverage float64 `json:"fiftyDayAverage"`
+}
+
+// Anything that can return the current quote for a symbol.
+type QuoteProvider interface {
+	// Name of the provider, used in logs.
+	Name() string
+	GetQuote(ctx context.Context, symbol string) (Quote, error)
+}
+
+// Fetches quotes from Yahoo Finance through piquette/finance-go.
+type YahooProvider struct{}
+
+func (y YahooProvider) Name() string { return "yahoo" }
+
+func (y YahooProvider) GetQuote(ctx context.Context, symbol string) (Quote, error) {
+	params := &equity.Params{Symbols: []string{symbol}}
+	params.Context = &ctx
+
+	iter := equity.ListP(params)
+	if !iter.Next() {
+		if err := iter.Err(); err != nil {
+			return Quote{}, err
+		}
+		return Quote{}, fmt.Errorf("yahoo returned no quote for %s", symbol)
+	}
+
+	return quoteFromEquity(iter.Equity()), nil
+}
+
+func quoteFromEquity(e *finance.Equity) Quote {
+	return Quote{
+		Symbol:          e.Symbol,
+		ShortName:       e.ShortName,
+		Currency:        e.CurrencyID,
+		Price:           e.RegularMarketPrice,
+		PreviousClose:   e.RegularMarketPreviousClose,
+		Change:          e.RegularMarketChange,
+		ChangePercent:   e.RegularMarketChangePercent,
+		FiftyDayAverage: e.FiftyDayAverage,
+	}
+}
+
+// Serves quotes from a JSON file mapping symbols to quotes, so the dashboard
+// can run without network access.
+type FixtureProvider struct {
+	Path string
+
+	mu     sync.Mutex
+	quotes map[string]Quote
+}
+
+func (f *FixtureProvider) Name() string { return "fixture" }
+
+// Reads the fixture file, the file is only read once.
+func (f *FixtureProvider) load() error {
+	if f.quotes != nil {
+		return nil
+	}
+
+	content, err := os.ReadFile(f.Path)
+	if err != nil {
+		return fmt.Errorf("cannot read quote fixture file: %w", err)
+	}
+
+	var quotes map[string]Quote
+	if err := json.Unmarshal(content, &quotes); err != nil {
+		return fmt.Errorf("cannot parse quote fixture file %s: %w", f.Path, err)
+	}
+
+	f.quotes = make(map[string]Quote, len(quotes))
+	for symbol, q := range quotes {
+		symbol = strings.ToUpper(symbol)
+		if q.Symbol == "" {
+			q.Symbol = symbol
+		}
+		f.quotes[symbol] = q
+	}
+	return nil
+}
+
+func (f *FixtureProvider) GetQuote(ctx context.Context, symbol string) (Quote, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.load(); err != nil {
+		return Quote{}, err
+	}
+
+	q, ok := f.quotes[strings.ToUpper(symbol)]
+	if !ok {
+		return Quote{}, fmt.Errorf("no fixture quote for %s", symbol)
+	}
+	return q, nil
+}
+
+// Create the quote provider named in the config key quotes.provider.
+func NewQuoteProvider(name string, fixturePath string) (QuoteProvider, error) {
+	switch name {
+	case "", "yahoo":
+		return YahooProvider{}, nil
+	case "fixture":
+		if fixturePath == "" {
+			return nil, fmt.Errorf("quotes.fixture_file must be set to use the fixture provider")
+		}
+		return &FixtureProvider{Path: fixturePath}, nil
+	default:
+		return nil, fmt.Errorf("unknown quote provider %q", name)
+	}
+}
+
+// The process-wide quote provider, Yahoo unless configured otherwise.
+var quoteProvider QuoteProvider = YahooProvider{}
+
+// Select the quote provider named in the config key quotes.provider, falling
+// back to Yahoo if the configured provider cannot be created.
+func ConfigureQuoteProvider(config *koanf.Koanf) {
+	provider, err := NewQuoteProvider(
+		config.String("quotes.provider"),
+		config.String("quotes.fixture_file"),
+	)
 	if err != nil {
-		return *q, err
+		log.Errorf("Cannot create quote provider, falling back to yahoo: %v", err)
+		provider = YahooProvider{}
 	}
-	return *q, nil
+	log.Infof("Using %s quote provider", provider.Name())
+	quoteProvider = provider
+}
+
+func GetQuoteProvider() QuoteProvider {
+	return quoteProvider
+}
+
+// The outcome of fetching a single symbol, Err is set if the fetch failed.
+type QuoteResult struct {
+	Symbol string
+	Quote  Quote
+	Err    error
+}
+
+// Fetch quotes for every symbol with at most concurrency requests in flight,
+// each symbol gets its own timeout so one slow symbol can't stall the rest.
+// Results are returned in the same order as symbols.
+func FetchQuotes(ctx context.Context, provider QuoteProvider, symbols []string, concurrency int, timeout time.Duration) []QuoteResult {
+	results := make([]QuoteResult, len(symbols))
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	if timeout <= 0 {
+		timeout = 3 * time.Second
+	}
+
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for range min(concurrency, len(symbols)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				symbolCtx, cancel := context.WithTimeout(ctx, timeout)
+				q, err := provider.GetQuote(symbolCtx, symbols[i])
+				cancel()
+				results[i] = QuoteResult{Symbol: symbols[i], Quote: q, Err: err}
+			}
+		}()
+	}
+
+	for i := range symbols {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	return results
+}
+
+// Fetch quotes for symbols using the configured provider, concurrency limit and timeout.
+func FetchConfiguredQuotes(config *koanf.Koanf, symbols []string) []QuoteResult {
+	return FetchQuotes(
+		context.Background(),
+		GetQuoteProvider(),
+		symbols,
+		config.Int("quotes.concurrency"),
+		config.Duration("quotes.timeout"),
+	)
 }
@@ -0,0 +1,79 @@
+package utils
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFixtureProvider(t *testing.T) {
+	provider, err := NewQuoteProvider("fixture", filepath.Join("fixtures", "quotes.json"))
+	if err != nil {
+		t.Fatalf("NewQuoteProvider: %v", err)
+	}
+
+	tests := []struct {
+		symbol string
+		want   Quote
+	}{
+		{"AAPL", Quote{Symbol: "AAPL", ShortName: "Apple Inc.", Currency: "USD", Price: 201.45, PreviousClose: 198.89, Change: 2.56, ChangePercent: 1.29, FiftyDayAverage: 205.12}},
+		// symbols are looked up case insensitively
+		{"spy", Quote{Symbol: "SPY", ShortName: "SPDR S&P 500", Currency: "USD", Price: 571.32, PreviousClose: 568.10, Change: 3.22, ChangePercent: 0.57, FiftyDayAverage: 560.45}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.symbol, func(t *testing.T) {
+			got, err := provider.GetQuote(context.Background(), tt.symbol)
+			if err != nil {
+				t.Fatalf("GetQuote: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("GetQuote(%q) = %+v, want %+v", tt.symbol, got, tt.want)
+			}
+		})
+	}
+
+	if _, err := provider.GetQuote(context.Background(), "ZZZZ"); err == nil {
+		t.Error("GetQuote of a symbol without a fixture should fail")
+	}
+}
+
+func TestFixtureProviderErrors(t *testing.T) {
+	if _, err := NewQuoteProvider("fixture", ""); err == nil {
+		t.Error("the fixture provider without a file should fail")
+	}
+
+	broken := filepath.Join(t.TempDir(), "quotes.json")
+	if err := os.WriteFile(broken, []byte("{not json"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	tests := map[string]string{
+		"missing file": filepath.Join(t.TempDir(), "missing.json"),
+		"invalid json": broken,
+	}
+	for name, path := range tests {
+		t.Run(name, func(t *testing.T) {
+			provider := &FixtureProvider{Path: path}
+			if _, err := provider.GetQuote(context.Background(), "AAPL"); err == nil {
+				t.Errorf("GetQuote with %s should fail", name)
+			}
+		})
+	}
+}
+
+func TestFetchQuotesKeepsOrder(t *testing.T) {
+	provider := &FixtureProvider{Path: filepath.Join("fixtures", "quotes.json")}
+	symbols := []string{"NVDA", "ZZZZ", "AAPL", "MSFT"}
+	results := FetchQuotes(context.Background(), provider, symbols, 2, 0)
+	if len(results) != len(symbols) {
+		t.Fatalf("got %d results, want %d", len(results), len(symbols))
+	}
+	for i, result := range results {
+		if result.Symbol != symbols[i] {
+			t.Errorf("result %d is %s, want %s", i, result.Symbol, symbols[i])
+		}
+		if (result.Err != nil) != (symbols[i] == "ZZZZ") {
+			t.Errorf("%s: unexpected error %v", result.Symbol, result.Err)
+		}
+	}
+}
@@ -0,0 +1,176 @@
+package utils
+
+import (
+	"encoding/json"
+	"slices"
+
+	"github.com/knadh/koanf/v2"
+)
+
+// A named group of symbols in a watchlist. The group with an empty name holds
+// the symbols that aren't in any group.
+type WatchlistGroup struct {
+	Name    string   `json:"name"`
+	Symbols []string `json:"symbols"`
+}
+
+// A named, ordered list of symbols split into groups. The ungrouped symbols
+// always come first, followed by the named groups in the order they were created.
+type Watchlist struct {
+	Name   string           `json:"name"`
+	Groups []WatchlistGroup `json:"groups"`
+}
+
+// Create a watchlist with every symbol ungrouped.
+func NewWatchlist(name string, symbols []string) *Watchlist {
+	return &Watchlist{
+		Name:   name,
+		Groups: []WatchlistGroup{{Name: "", Symbols: slices.Clone(symbols)}},
+	}
+}
+
+// Name of the watchlist made from dashboard.tickers.
+const DefaultWatchlistName = "Watchlist"
+
+// Read the watchlists from the config. dashboard.watchlists defines several
+// named lists, when it's not set dashboard.tickers is used as a single list.
+func WatchlistsFromConfig(config *koanf.Koanf) []*Watchlist {
+	var lists []*Watchlist
+	for _, list := range config.Slices("dashboard.watchlists") {
+		name := list.String("name")
+		if name == "" {
+			continue
+		}
+		lists = append(lists, NewWatchlist(name, list.Strings("tickers")))
+	}
+
+	if len(lists) == 0 {
+		lists = append(lists, NewWatchlist(DefaultWatchlistName, config.Strings("dashboard.tickers")))
+	}
+	return lists
+}
+
+// Older state files saved the watchlist as a plain list of symbols.
+func (w *Watchlist) UnmarshalJSON(data []byte) error {
+	var symbols []string
+	if err := json.Unmarshal(data, &symbols); err == nil {
+		*w = *NewWatchlist(DefaultWatchlistName, symbols)
+		return nil
+	}
+
+	type watchlist Watchlist
+	if err := json.Unmarshal(data, (*watchlist)(w)); err != nil {
+		return err
+	}
+	if w.Name == "" {
+		w.Name = DefaultWatchlistName
+	}
+	w.normalize()
+	return nil
+}
+
+// Make sure the ungrouped group exists and comes first, and drop empty named groups.
+func (w *Watchlist) normalize() {
+	ungrouped := WatchlistGroup{}
+	var groups []WatchlistGroup
+	for _, g := range w.Groups {
+		if g.Name == "" {
+			ungrouped.Symbols = append(ungrouped.Symbols, g.Symbols...)
+		} else if len(g.Symbols) > 0 {
+			groups = append(groups, g)
+		}
+	}
+	w.Groups = append([]WatchlistGroup{ungrouped}, groups...)
+}
+
+// Every symbol in the watchlist, in display order.
+func (w *Watchlist) Symbols() []string {
+	var symbols []string
+	for _, g := range w.Groups {
+		symbols = append(symbols, g.Symbols...)
+	}
+	return symbols
+}
+
+// Returns the index of the group containing symbol and its index in that group.
+func (w *Watchlist) find(symbol string) (int, int, bool) {
+	for gi, g := range w.Groups {
+		if si := slices.Index(g.Symbols, symbol); si >= 0 {
+			return gi, si, true
+		}
+	}
+	return 0, 0, false
+}
+
+func (w *Watchlist) Contains(symbol string) bool {
+	_, _, ok := w.find(symbol)
+	return ok
+}
+
+// Add a symbol to the end of the ungrouped symbols, returns false if it was already in the watchlist.
+func (w *Watchlist) Add(symbol string) bool {
+	if w.Contains(symbol) {
+		return false
+	}
+	w.normalize()
+	w.Groups[0].Symbols = append(w.Groups[0].Symbols, symbol)
+	return true
+}
+
+// Remove a symbol from the watchlist, returns false if it wasn't in the watchlist.
+func (w *Watchlist) Remove(symbol string) bool {
+	gi, si, ok := w.find(symbol)
+	if !ok {
+		return false
+	}
+	w.Groups[gi].Symbols = slices.Delete(w.Groups[gi].Symbols, si, si+1)
+	w.normalize()
+	return true
+}
+
+// Move a symbol one place up (delta -1) or down (delta 1). Moving past the
+// edge of a group moves the symbol into the neighbouring group.
+func (w *Watchlist) Move(symbol string, delta int) {
+	gi, si, ok := w.find(symbol)
+	if !ok {
+		return
+	}
+
+	symbols := w.Groups[gi].Symbols
+	target := si + delta
+	if target >= 0 && target < len(symbols) {
+		symbols[si], symbols[target] = symbols[target], symbols[si]
+		return
+	}
+
+	// crossing into the neighbouring group
+	next := gi + delta
+	if next < 0 || next >= len(w.Groups) {
+		return
+	}
+	w.Groups[gi].Symbols = slices.Delete(symbols, si, si+1)
+	if delta < 0 {
+		w.Groups[next].Symbols = append(w.Groups[next].Symbols, symbol)
+	} else {
+		w.Groups[next].Symbols = slices.Insert(w.Groups[next].Symbols, 0, symbol)
+	}
+	w.normalize()
+}
+
+// Put a symbol into the named group, creating the group if it doesn't exist.
+// An empty name removes the symbol from its group.
+func (w *Watchlist) SetGroup(symbol string, name string) {
+	gi, si, ok := w.find(symbol)
+	if !ok || w.Groups[gi].Name == name {
+		return
+	}
+	w.Groups[gi].Symbols = slices.Delete(w.Groups[gi].Symbols, si, si+1)
+
+	target := slices.IndexFunc(w.Groups, func(g WatchlistGroup) bool { return g.Name == name })
+	if target < 0 {
+		w.Groups = append(w.Groups, WatchlistGroup{Name: name})
+		target = len(w.Groups) - 1
+	}
+	w.Groups[target].Symbols = append(w.Groups[target].Symbols, symbol)
+	w.normalize()
+}
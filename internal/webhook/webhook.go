@@ -0,0 +1,285 @@
+// Sends alerts and matching headlines to configured webhook targets.
+package webhook
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"gloomberg/internal/alerts"
+	"gloomberg/internal/scraping"
+
+	"github.com/charmbracelet/log"
+	"github.com/knadh/koanf/v2"
+)
+
+// Kinds of events that can be sent.
+const (
+	AlertEvent    = "alert"
+	HeadlineEvent = "headline"
+)
+
+// Something that happened, used as the data for payload templates.
+type Event struct {
+	Type string    `json:"type"`
+	Time time.Time `json:"time"`
+	// Human readable description of the event
+	Text string `json:"text"`
+
+	// Set on alert events
+	Alert *alerts.Alert `json:"alert,omitempty"`
+	// The value that set the alert off
+	Value float64 `json:"value,omitempty"`
+
+	// Set on headline events
+	Article *scraping.NewsArticle `json:"article,omitempty"`
+	// The keyword the headline matched
+	Keyword string `json:"keyword,omitempty"`
+}
+
+// A URL that receives events.
+type Target struct {
+	URL string
+	// Event types sent to this target, every type if empty
+	Events []string
+	// Headlines are only sent if they contain one of these keywords
+	Keywords []string
+	// Extra headers to send, e.g. for authentication
+	Headers map[string]string
+	// Template for the request body, the event as JSON if nil
+	Template *template.Template
+}
+
+func (t Target) wants(eventType string) bool {
+	if len(t.Events) == 0 {
+		return true
+	}
+	for _, e := range t.Events {
+		if e == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// Returns the first keyword that appears in the article, ignoring case.
+func (t Target) match(article scraping.NewsArticle) (string, bool) {
+	text := strings.ToLower(article.Title + " " + article.Content)
+	for _, keyword := range t.Keywords {
+		if strings.Contains(text, strings.ToLower(keyword)) {
+			return keyword, true
+		}
+	}
+	return "", false
+}
+
+// Build the request body for an event.
+func (t Target) payload(ev Event) ([]byte, error) {
+	if t.Template == nil {
+		return json.Marshal(ev)
+	}
+	var b bytes.Buffer
+	if err := t.Template.Execute(&b, ev); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+var templateFuncs = template.FuncMap{
+	// marshal a value to JSON, for putting strings into JSON templates safely
+	"json": func(v any) (string, error) {
+		b, err := json.Marshal(v)
+		return string(b), err
+	},
+}
+
+type Dispatcher struct {
+	targets []Target
+	client  *http.Client
+	// how many times to retry a failed delivery
+	retries int
+	// how long to wait before the first retry, doubled after each retry
+	backoff time.Duration
+	// headlines older than this are never sent
+	maxHeadlineAge time.Duration
+
+	mu sync.Mutex
+	// when the headlines that were already sent were published, keyed by target and article
+	sent map[string]time.Time
+}
+
+// The dispatcher shared by every session in this process, set up in main.
+var Shared *Dispatcher
+
+// Create a dispatcher from the webhooks config.
+func New(config *koanf.Koanf) *Dispatcher {
+	d := &Dispatcher{
+		client:         &http.Client{Timeout: config.Duration("webhook.timeout")},
+		retries:        config.Int("webhook.retries"),
+		backoff:        config.Duration("webhook.backoff"),
+		maxHeadlineAge: config.Duration("webhook.headline_max_age"),
+		sent:           make(map[string]time.Time),
+	}
+
+	for i, c := range config.Slices("webhooks") {
+		target := Target{
+			URL:      c.String("url"),
+			Events:   c.Strings("events"),
+			Keywords: c.Strings("keywords"),
+			Headers:  c.StringMap("headers"),
+		}
+		if target.URL == "" {
+			log.Warnf("Webhook %d has no url, skipping", i)
+			continue
+		}
+		if text := c.String("template"); text != "" {
+			tmpl, err := template.New(target.URL).Funcs(templateFuncs).Parse(text)
+			if err != nil {
+				log.Errorf("Invalid template for webhook %s, skipping: %v", target.URL, err)
+				continue
+			}
+			target.Template = tmpl
+		}
+		d.targets = append(d.targets, target)
+	}
+
+	log.Infof("Loaded %d webhook targets", len(d.targets))
+	return d
+}
+
+// Send an alert that fired to every target that wants alerts.
+func (d *Dispatcher) Alert(fired alerts.Fired) {
+	if d == nil {
+		return
+	}
+	ev := Event{
+		Type:  AlertEvent,
+		Time:  time.Now(),
+		Text:  fired.String(),
+		Alert: &fired.Alert,
+		Value: fired.Value,
+	}
+	for _, t := range d.targets {
+		if t.wants(AlertEvent) {
+			go d.deliver(t, ev)
+		}
+	}
+}
+
+// Send every recent headline that matches a target's keywords, each headline
+// is only sent to a target once.
+func (d *Dispatcher) Headlines(news []scraping.NewsArticle) {
+	if d == nil {
+		return
+	}
+	d.pruneSent()
+	for _, t := range d.targets {
+		if !t.wants(HeadlineEvent) || len(t.Keywords) == 0 {
+			continue
+		}
+		for _, article := range news {
+			if d.maxHeadlineAge > 0 && time.Since(article.PublicationDate) > d.maxHeadlineAge {
+				continue
+			}
+			keyword, ok := t.match(article)
+			if !ok || !d.markSent(t, article) {
+				continue
+			}
+
+			ev := Event{
+				Type:    HeadlineEvent,
+				Time:    time.Now(),
+				Text:    fmt.Sprintf("%s (%s)", article.Title, article.Source),
+				Article: &article,
+				Keyword: keyword,
+			}
+			go d.deliver(t, ev)
+		}
+	}
+}
+
+// Remember that article was sent to t, returns false if it already was.
+func (d *Dispatcher) markSent(t Target, article scraping.NewsArticle) bool {
+	key := t.URL + "\x00" + article.URL + "\x00" + article.Title
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if _, ok := d.sent[key]; ok {
+		return false
+	}
+	d.sent[key] = article.PublicationDate
+	return true
+}
+
+// Forget headlines older than maxHeadlineAge, they're skipped before
+// they're looked up so remembering them only uses memory.
+func (d *Dispatcher) pruneSent() {
+	if d.maxHeadlineAge <= 0 {
+		return
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for key, published := range d.sent {
+		if time.Since(published) > d.maxHeadlineAge {
+			delete(d.sent, key)
+		}
+	}
+}
+
+// POST the event to the target, retrying with exponential backoff.
+func (d *Dispatcher) deliver(t Target, ev Event) {
+	body, err := t.payload(ev)
+	if err != nil {
+		log.Errorf("Cannot build webhook payload for %s: %v", t.URL, err)
+		return
+	}
+
+	wait := d.backoff
+	for attempt := 0; ; attempt++ {
+		retry, err := d.post(t, body)
+		if err == nil {
+			log.Infof("Sent %s event to %s", ev.Type, t.URL)
+			return
+		}
+		if !retry || attempt >= d.retries {
+			log.Errorf("Giving up sending %s event to %s: %v", ev.Type, t.URL, err)
+			return
+		}
+		log.Warnf("Webhook %s failed, retry %d/%d in %s: %v", t.URL, attempt+1, d.retries, wait, err)
+		time.Sleep(wait)
+		wait *= 2
+	}
+}
+
+// Send a single request, returns whether a failure is worth retrying.
+func (d *Dispatcher) post(t Target, body []byte) (bool, error) {
+	req, err := http.NewRequest("POST", t.URL, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "gloom")
+	for k, v := range t.Headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		// network errors and timeouts
+		return true, err
+	}
+	resp.Body.Close()
+
+	switch {
+	case resp.StatusCode < 300:
+		return false, nil
+	case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500:
+		return true, fmt.Errorf("server returned %s", resp.Status)
+	default:
+		return false, fmt.Errorf("server returned %s", resp.Status)
+	}
+}